@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,16 +29,187 @@ type GoSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Pattern="^([a-z0-9א-ת]+)(-[a-z0-9א-ת]+)*$"
-	// the shorten name for your link
+	// the shorten name for your link, defaults to the resource name
 	// format must kebab case e.g.: "my-first-go-link"
-	Alias string `json:"alias"`
+	Alias string `json:"alias,omitempty"`
 
-	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:items:Pattern="^([a-z0-9א-ת]+)(-[a-z0-9א-ת]+)*$"
+	// more aliases that redirect to the same url, each with its own credentials
+	AdditionalAliases []string `json:"additionalAliases,omitempty"`
+
+	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Pattern="^https?://.*$"
-	// the url that go/your-alias will redirect to
-	Url string `json:"url"`
+	// the url that go/your-alias will redirect to,
+	// exactly one of url, targetRef, urlFrom and urlTemplate must be set
+	Url string `json:"url,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// an object in the namespace of the resource whose externally reachable url go/your-alias will redirect to
+	TargetRef *GoTargetRef `json:"targetRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// reads the url from a key of a ConfigMap or Secret in the namespace of the resource,
+	// a Secret must be annotated with shmila.iaf/url-source=true. A url read from a Secret
+	// is read again when the resource is resynced, changes of a ConfigMap are noticed right away.
+	UrlFrom *GoURLSource `json:"urlFrom,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// a Go template of the url with {{ .Values.key }} placeholders that are filled from valuesFrom
+	UrlTemplate string `json:"urlTemplate,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ConfigMaps in the namespace of the resource whose keys are the .Values of urlTemplate,
+	// a key of a later ConfigMap overrides the same key of an earlier one
+	ValuesFrom []corev1.LocalObjectReference `json:"valuesFrom,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern="^https?://.*$"
+	// the url that go/your-alias/a/b redirects to, with %s or {name} placeholders that are filled
+	// in order with the path segments after the alias, only used by link servers that support it
+	UrlPattern string `json:"urlPattern,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// redirect go/your-alias/a/b to the url with /a/b appended, only used by link servers that support it
+	PathPassthrough bool `json:"pathPassthrough,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the name of the GoLinkServer to publish the link to, defaults to the default server
+	ServerRef string `json:"serverRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// takes ownership of a link that already exists on the link server
+	AdoptFrom *GoAdoptFrom `json:"adoptFrom,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +kubebuilder:default=Delete
+	// what happens to the link on the link server when the resource is deleted,
+	// a retained link keeps its credentials secret and is adopted by the next Go resource with its alias
+	// in the same namespace, Go resources in other namespaces can not claim the alias meanwhile
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// how long the previous alias keeps redirecting after the alias is renamed,
+	// by default it is deleted as soon as the new alias is created
+	RenameGracePeriod *metav1.Duration `json:"renameGracePeriod,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// when the link is removed from the link server, can not be set together with ttl
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// how long after the creation of the resource the link expires, can not be set together with expiresAt
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the link is held in Pending and not published before this time
+	ActiveFrom *metav1.Time `json:"activeFrom,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// delete the resource itself when the link expires, instead of only removing the link from the link server
+	DeleteOnExpiry bool `json:"deleteOnExpiry,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// periodically checks that the url is reachable, a url with a loopback or link-local address is
+	// reported as unreachable without a request, and so is a private address unless the operator allows it
+	Probe *GoProbe `json:"probe,omitempty"`
+}
+
+// health probing of the url of a link
+type GoProbe struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=30
+	// +kubebuilder:default=300
+	// how often the url is probed
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +kubebuilder:default=5
+	// timeout of a single probe
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=HEAD;GET
+	// +kubebuilder:default=HEAD
+	// the request method, a HEAD that the target does not allow is retried as a GET
+	Method string `json:"method,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the status codes of a reachable url, any 2xx or 3xx by default
+	AcceptedStatusCodes []int `json:"acceptedStatusCodes,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// follow redirects and check the status code of the final response
+	FollowRedirects bool `json:"followRedirects,omitempty"`
+}
+
+// Deletion policies of a Go resource
+const (
+	DeletionPolicyDelete string = "Delete"
+	DeletionPolicyRetain string = "Retain"
+)
+
+// RetainedLabel marks the credentials secret of a retained link, it is adopted by the next
+// Go resource with its alias in the namespace of the deleted resource
+const RetainedLabel = "shmila.iaf/retained"
+
+// URLSourceAnnotation must be "true" on a Secret for Go resources to read their url from it,
+// otherwise anyone who may create a Go resource could publish any Secret of its namespace
+const URLSourceAnnotation = "shmila.iaf/url-source"
+
+// a key of a ConfigMap or Secret that holds a url, exactly one of the refs must be set
+type GoURLSource struct {
+	// +kubebuilder:validation:Optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// Kinds of objects that a link can target
+const (
+	TargetKindService   string = "Service"
+	TargetKindIngress   string = "Ingress"
+	TargetKindHTTPRoute string = "HTTPRoute"
+)
+
+// an in-cluster object that a link redirects to
+type GoTargetRef struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Service;Ingress;HTTPRoute
+	// the kind of the object, an HTTPRoute is a Gateway API route
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:Required
+	// the name of the object
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// the host of an Ingress or HTTPRoute with several hosts, defaults to the first host
+	Host string `json:"host,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the name or number of a Service port, defaults to the first port
+	Port string `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern="^/.*$"
+	// a path appended to the url of the object
+	Path string `json:"path,omitempty"`
+}
+
+// the credentials of an existing link to adopt
+type GoAdoptFrom struct {
+	// +kubebuilder:validation:Required
+	// a secret in the namespace of the Go resource that holds the password of the existing link,
+	// it is copied into the operator secret when the link is first reconciled
+	PasswordSecretRef corev1.SecretKeySelector `json:"passwordSecretRef"`
 }
 
 // Status of your GoLink
@@ -45,18 +217,139 @@ type GoStatus struct {
 	// +kubebuilder:validation:Optional
 	Message string `json:"message"`
 	// +kubebuilder:validation:Optional
+	// one of Active, Pending, Failure, Deleting or Expired
 	State string `json:"state"`
 	// +kubebuilder:validation:Optional
 	ReconcileTime string `json:"reconcileTime"`
+
+	// +kubebuilder:validation:Optional
+	// the generation of the spec that was last reconciled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the url the link redirects to, spec.url or the url resolved from its other sources.
+	// A url read from a Secret shows as secret:<name>/<key>@<resourceVersion> instead.
+	ResolvedURL string `json:"resolvedURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the url that was last pushed to the link server
+	LastSyncedURL string `json:"lastSyncedURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the GoLinkServer the link is published to, empty for the operator default server
+	Server string `json:"server,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the secret that holds the credentials of the link
+	CredentialsSecretRef *corev1.SecretReference `json:"credentialsSecretRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// when the password of the link was last rotated
+	LastPasswordRotation *metav1.Time `json:"lastPasswordRotation,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the alias the link was renamed from, it redirects to the url until previousAliasExpiresAt
+	PreviousAlias string `json:"previousAlias,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// when the previous alias is deleted from the link server
+	PreviousAliasExpiresAt *metav1.Time `json:"previousAliasExpiresAt,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=alias
+	// the sync state of each additional alias
+	AdditionalAliases []GoAliasStatus `json:"additionalAliases,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// a warning event was emitted for the upcoming expiry
+	ExpiryWarned bool `json:"expiryWarned,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the result of the last probe of the url
+	LastProbe *GoProbeResult `json:"lastProbe,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// the latest observations of the link state
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// the sync state of a single additional alias
+type GoAliasStatus struct {
+	Alias string `json:"alias"`
+
+	// one of Active, Pending or Failure
+	State string `json:"state"`
+
+	// +kubebuilder:validation:Optional
+	// details of the last failure
+	Message string `json:"message,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the url that was last pushed to the link server for this alias
+	LastSyncedURL string `json:"lastSyncedURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the url pattern that was last pushed with the url, empty when the link server does not support it
+	LastSyncedURLPattern string `json:"lastSyncedURLPattern,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// whether path passthrough was last pushed with the url
+	LastSyncedPathPassthrough bool `json:"lastSyncedPathPassthrough,omitempty"`
 }
 
+// the result of probing the url of a link
+type GoProbeResult struct {
+	// when the probe was sent
+	Time metav1.Time `json:"time"`
+
+	// the probed url
+	Url string `json:"url"`
+
+	// the status code is accepted by the probe
+	Reachable bool `json:"reachable"`
+
+	// +kubebuilder:validation:Optional
+	// the status code of the response
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// why no response was received
+	Error string `json:"error,omitempty"`
+}
+
+// Condition types of a Go resource
+const (
+	// the link is synced and all the other conditions are true
+	ConditionReady string = "Ready"
+	// the link on the link server matches the spec
+	ConditionSynced string = "Synced"
+	// the alias is not owned by someone else on the link server
+	ConditionAliasAvailable string = "AliasAvailable"
+	// the credentials secret of the link exists and is readable
+	ConditionCredentialsReady string = "CredentialsReady"
+	// the link is allowed by the GoLinkPolicies of its namespace
+	ConditionPolicyCompliant string = "PolicyCompliant"
+	// all the additional aliases are synced to the link server
+	ConditionAdditionalAliasesSynced string = "AdditionalAliasesSynced"
+	// the last probe of the url got an accepted response
+	ConditionTargetReachable string = "TargetReachable"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Alias",type="string",JSONPath=".spec.alias",description="The shorten name"
 //+kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url",description="The URL"
 //+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.state"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 //+kubebuilder:printcolumn:name="Details",type="string",priority=1,JSONPath=".status.message"
+//+kubebuilder:printcolumn:name="Server",type="string",priority=1,JSONPath=".status.server"
+//+kubebuilder:printcolumn:name="Resolved URL",type="string",priority=1,JSONPath=".status.resolvedURL"
 
 // Go is the Schema for the goes API
 type Go struct {
@@ -67,6 +360,22 @@ type Go struct {
 	Status GoStatus `json:"status,omitempty"`
 }
 
+// ExpiryTime returns when the link expires according to expiresAt or ttl, or nil when it does not expire
+func (r *Go) ExpiryTime() *metav1.Time {
+	if r.Spec.ExpiresAt != nil {
+		return r.Spec.ExpiresAt
+	}
+	if r.Spec.TTL != nil {
+		return &metav1.Time{Time: r.CreationTimestamp.Add(r.Spec.TTL.Duration)}
+	}
+	return nil
+}
+
+// Aliases returns the alias followed by the additional aliases
+func (r *Go) Aliases() []string {
+	return append([]string{r.Spec.Alias}, r.Spec.AdditionalAliases...)
+}
+
 //+kubebuilder:object:root=true
 
 // GoList contains a list of Go
@@ -0,0 +1,293 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// urlPattern is the pattern of spec.url, rendered urls must match it as well
+var urlPattern = regexp.MustCompile(`^https?://.*$`)
+
+// ValidateURL rejects a url that does not match the pattern of spec.url,
+// the error does not quote the url since it may be read from a Secret
+func ValidateURL(rawURL string) error {
+	if !urlPattern.MatchString(rawURL) {
+		return fmt.Errorf("url must start with http:// or https://")
+	}
+	return nil
+}
+
+// RenderedURL is the url of a Go resource as read or rendered from its sources
+// +kubebuilder:object:generate=false
+type RenderedURL struct {
+	URL string
+	// Redacted stands in for a url read from a Secret in the status, events and logs.
+	// It names the secret key and its resource version, so a changed secret still shows.
+	Redacted string
+}
+
+// Display returns the url, or its redacted form when it is read from a Secret
+func (u RenderedURL) Display() string {
+	if u.Redacted != "" {
+		return u.Redacted
+	}
+	return u.URL
+}
+
+// URLFromSecret returns whether the url of the resource is read from a Secret,
+// such a url must not show in the status, events or logs
+func (r *Go) URLFromSecret() bool {
+	return r.Spec.UrlFrom != nil && r.Spec.UrlFrom.SecretKeyRef != nil
+}
+
+// RenderURL returns the url of a Go resource with urlFrom or urlTemplate by reading
+// the ConfigMaps and Secrets they reference, or spec.url for any other resource
+func (r *Go) RenderURL(ctx context.Context, c client.Reader) (RenderedURL, error) {
+	if r.Spec.UrlFrom != nil {
+		return r.readURLSource(ctx, c)
+	} else if r.Spec.UrlTemplate != "" {
+		rendered, err := r.renderURLTemplate(ctx, c)
+		return RenderedURL{URL: rendered}, err
+	}
+	return RenderedURL{URL: r.Spec.Url}, nil
+}
+
+func (r *Go) readURLSource(ctx context.Context, c client.Reader) (RenderedURL, error) {
+	source := r.Spec.UrlFrom
+	if ref := source.ConfigMapKeyRef; ref != nil {
+		configMap := corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: ref.Name}, &configMap); err != nil {
+			return RenderedURL{}, fmt.Errorf("failed to read configmap %s: %w", ref.Name, err)
+		}
+		value, ok := configMap.Data[ref.Key]
+		if !ok {
+			return RenderedURL{}, fmt.Errorf("configmap %s has no key %s", ref.Name, ref.Key)
+		}
+		return RenderedURL{URL: strings.TrimSpace(value)}, nil
+	} else if ref := source.SecretKeyRef; ref != nil {
+		secret := corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: ref.Name}, &secret); err != nil {
+			return RenderedURL{}, fmt.Errorf("failed to read secret %s: %w", ref.Name, err)
+		}
+		if err := allowsURLSource(&secret); err != nil {
+			return RenderedURL{}, err
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			return RenderedURL{}, fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+		}
+		return RenderedURL{
+			URL:      strings.TrimSpace(string(value)),
+			Redacted: "secret:" + ref.Name + "/" + ref.Key + "@" + secret.ResourceVersion,
+		}, nil
+	}
+	return RenderedURL{}, fmt.Errorf("urlFrom must set one of configMapKeyRef and secretKeyRef")
+}
+
+// allowsURLSource returns an error unless secret opted in to holding the url of Go resources
+func allowsURLSource(secret *corev1.Secret) error {
+	if secret.Annotations[URLSourceAnnotation] != "true" {
+		return fmt.Errorf("secret %s is not annotated with %s=true", secret.Name, URLSourceAnnotation)
+	}
+	return nil
+}
+
+func (r *Go) renderURLTemplate(ctx context.Context, c client.Reader) (string, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(r.Spec.UrlTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid urlTemplate: %w", err)
+	}
+	values := map[string]string{}
+	for _, ref := range r.Spec.ValuesFrom {
+		configMap := corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: ref.Name}, &configMap); err != nil {
+			return "", fmt.Errorf("failed to read configmap %s: %w", ref.Name, err)
+		}
+		for key, value := range configMap.Data {
+			values[key] = value
+		}
+	}
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, struct{ Values map[string]string }{values}); err != nil {
+		return "", fmt.Errorf("failed to render urlTemplate: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// urlPatternPart is literal text or a placeholder of a url pattern
+type urlPatternPart struct {
+	literal     string
+	placeholder bool
+	name        string
+}
+
+var placeholderName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseURLPattern splits a url pattern into literals and placeholders, a pattern uses
+// either %s or {name} placeholders and %% and {{ }} stand for literal % and braces
+func parseURLPattern(pattern string) ([]urlPatternPart, error) {
+	parts := []urlPatternPart{}
+	literal := strings.Builder{}
+	names := map[string]bool{}
+	positional, named := false, false
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, urlPatternPart{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '%':
+			if i+1 == len(pattern) {
+				return nil, fmt.Errorf("url pattern ends with a lone %%")
+			}
+			i++
+			switch pattern[i] {
+			case '%':
+				literal.WriteByte('%')
+			case 's':
+				flush()
+				parts = append(parts, urlPatternPart{placeholder: true})
+				positional = true
+			default:
+				// a percent encoded byte such as %20 is kept as is
+				if i+1 < len(pattern) && isHex(pattern[i]) && isHex(pattern[i+1]) {
+					literal.WriteString(pattern[i-1 : i+2])
+					i++
+					continue
+				}
+				return nil, fmt.Errorf("url pattern has an unsupported verb %%%c, only %%s is supported", pattern[i])
+			}
+		case c == '{' && strings.HasPrefix(pattern[i:], "{{"):
+			literal.WriteByte('{')
+			i++
+		case c == '}' && strings.HasPrefix(pattern[i:], "}}"):
+			literal.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("url pattern has an unclosed {")
+			}
+			name := pattern[i+1 : i+end]
+			if !placeholderName.MatchString(name) {
+				return nil, fmt.Errorf("url pattern placeholder {%s} must be a name of letters, digits and _", name)
+			} else if names[name] {
+				return nil, fmt.Errorf("url pattern placeholder {%s} is used more than once", name)
+			}
+			names[name] = true
+			flush()
+			parts = append(parts, urlPatternPart{placeholder: true, name: name})
+			named = true
+			i += end
+		case c == '}':
+			return nil, fmt.Errorf("url pattern has an unopened }")
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	flush()
+	if positional && named {
+		return nil, fmt.Errorf("url pattern can not mix %%s and {name} placeholders")
+	} else if !positional && !named {
+		return nil, fmt.Errorf("url pattern has no %%s or {name} placeholders")
+	}
+	return parts, nil
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// ValidateURLPattern rejects a url pattern whose placeholders are not well-formed
+func ValidateURLPattern(pattern string) error {
+	_, err := parseURLPattern(pattern)
+	return err
+}
+
+// ExpandURLPattern fills the placeholders of pattern in order with the path segments
+func ExpandURLPattern(pattern string, segments []string) (string, error) {
+	parts, err := parseURLPattern(pattern)
+	if err != nil {
+		return "", err
+	}
+	expanded := strings.Builder{}
+	next := 0
+	for _, part := range parts {
+		if !part.placeholder {
+			expanded.WriteString(part.literal)
+			continue
+		}
+		if next == len(segments) {
+			return "", fmt.Errorf("url pattern needs more than %d path segments", len(segments))
+		}
+		expanded.WriteString(url.PathEscape(segments[next]))
+		next++
+	}
+	if next < len(segments) {
+		return "", fmt.Errorf("url pattern takes %d path segments, got %d", next, len(segments))
+	}
+	return expanded.String(), nil
+}
+
+// samplePatternURL fills the placeholders of a url pattern with a sample segment, so
+// the url can be parsed to check its host. A literal %% is kept percent encoded.
+func samplePatternURL(pattern string) (string, error) {
+	parts, err := parseURLPattern(strings.ReplaceAll(pattern, "%%", "%25"))
+	if err != nil {
+		return "", err
+	}
+	sample := strings.Builder{}
+	for _, part := range parts {
+		if part.placeholder {
+			sample.WriteString("x")
+		} else {
+			sample.WriteString(part.literal)
+		}
+	}
+	return sample.String(), nil
+}
+
+// URLSources returns the ConfigMaps and Secrets the url is read from, as "Kind/name"
+func (r *Go) URLSources() []string {
+	sources := []string{}
+	if source := r.Spec.UrlFrom; source != nil {
+		if source.ConfigMapKeyRef != nil {
+			sources = append(sources, "ConfigMap/"+source.ConfigMapKeyRef.Name)
+		}
+		if source.SecretKeyRef != nil {
+			sources = append(sources, "Secret/"+source.SecretKeyRef.Name)
+		}
+	}
+	if r.Spec.UrlTemplate != "" {
+		for _, ref := range r.Spec.ValuesFrom {
+			sources = append(sources, "ConfigMap/"+ref.Name)
+		}
+	}
+	return sources
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateURLPattern(t *testing.T) {
+	tests := map[string]bool{
+		"https://jira.example.com/browse/%s":       true,
+		"https://example.com/%s/issues/%s?q=100%%": true,
+		"https://example.com/{org}/{repo}":         true,
+		"https://example.com/search%20for/{term}":  true,
+		"https://example.com/{{literal}}/{term}":   true,
+		"https://example.com/":                     false,
+		"https://example.com/%d":                   false,
+		"https://example.com/%s/{name}":            false,
+		"https://example.com/{name":                false,
+		"https://example.com/name}/%s":             false,
+		"https://example.com/{1name}":              false,
+		"https://example.com/{name}/{name}":        false,
+		"https://example.com/%":                    false,
+	}
+	for pattern, valid := range tests {
+		if err := ValidateURLPattern(pattern); (err == nil) != valid {
+			t.Errorf("ValidateURLPattern(%q) = %v, want valid %v", pattern, err, valid)
+		}
+	}
+}
+
+func TestExpandURLPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		segments []string
+		want     string
+		fails    bool
+	}{
+		{"https://jira.example.com/browse/%s", []string{"OPS-12"}, "https://jira.example.com/browse/OPS-12", false},
+		{"https://example.com/{org}/{repo}?q=100%%", []string{"acme", "web app"}, "https://example.com/acme/web%20app?q=100%", false},
+		{"https://example.com/%s/%s", []string{"one"}, "", true},
+		{"https://example.com/%s", []string{"one", "two"}, "", true},
+	}
+	for _, test := range tests {
+		got, err := ExpandURLPattern(test.pattern, test.segments)
+		if (err != nil) != test.fails || got != test.want {
+			t.Errorf("ExpandURLPattern(%q, %v) = %q, %v, want %q", test.pattern, test.segments, got, err, test.want)
+		}
+	}
+}
+
+func TestRenderURLFromSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	secret := func(name string, annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+			Data:       map[string][]byte{"url": []byte("https://internal.example.com\n")},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		secret("link", map[string]string{URLSourceAnnotation: "true"}),
+		secret("database", nil),
+	).Build()
+
+	tests := []struct {
+		secret  string
+		want    string
+		wantErr bool
+	}{
+		{secret: "link", want: "https://internal.example.com"},
+		{secret: "database", wantErr: true},
+		{secret: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := &Go{ObjectMeta: metav1.ObjectMeta{Name: "docs", Namespace: "default"}}
+		r.Spec.UrlFrom = &GoURLSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: tt.secret},
+			Key:                  "url",
+		}}
+		rendered, err := r.RenderURL(context.Background(), c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("secret %s: error %v, want error %t", tt.secret, err, tt.wantErr)
+		} else if rendered.URL != tt.want || (!tt.wantErr && rendered.Redacted == "") {
+			t.Errorf("secret %s: url %q redacted as %q, want %q redacted", tt.secret, rendered.URL, rendered.Redacted, tt.want)
+		}
+	}
+}
@@ -17,50 +17,358 @@ limitations under the License.
 package v1
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
 
+	"golang.org/x/net/idna"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // log is for logging in this package.
 var golog = logf.Log.WithName("go-resource")
 
-func (r *Go) SetupWebhookWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewWebhookManagedBy(mgr).
+// AliasField indexes Go resources by their alias and additional aliases
+const AliasField = ".spec.alias"
+
+// CreatedByAnnotation holds the user that created the Go resource
+const CreatedByAnnotation = "shmila.iaf/created-by"
+
+// PasswordRotationAnnotation overrides the password rotation interval of a
+// single Go resource, as a duration such as "720h", "0" disables rotation
+const PasswordRotationAnnotation = "shmila.iaf/password-rotation-interval"
+
+// SetupWebhookWithManager registers the webhooks of Go resources, the GoLinkPolicies
+// of clusterNamespace apply to the Go resources of every namespace
+func (r *Go) SetupWebhookWithManager(mgr ctrl.Manager, clusterNamespace string) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &Go{}, AliasField, func(obj client.Object) []string {
+		return obj.(*Go).Aliases()
+	}); err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
 		For(r).
-		Complete()
+		WithValidator(&goValidator{client: mgr.GetClient(), clusterNamespace: clusterNamespace}).
+		Complete(); err != nil {
+		return err
+	}
+	mgr.GetWebhookServer().Register("/mutate-shmila-iaf-v1-go", &webhook.Admission{Handler: &goDefaulter{}})
+	return nil
+}
+
+//+kubebuilder:webhook:path=/mutate-shmila-iaf-v1-go,mutating=true,failurePolicy=fail,sideEffects=None,groups=shmila.iaf,resources=goes,verbs=create;update,versions=v1,name=mgo.kb.io,admissionReviewVersions=v1
+
+// goDefaulter fills in the alias, normalizes the url and records who created the resource.
+// It is a plain admission handler since a CustomDefaulter can not see the admission request.
+type goDefaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &goDefaulter{}
+
+func (d *goDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler
+func (d *goDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	r := &Go{}
+	if err := d.decoder.Decode(req, r); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	golog.Info("default", "name", r.Name)
+
+	r.Spec.Alias = strings.TrimSpace(r.Spec.Alias)
+	if r.Spec.Alias == "" {
+		r.Spec.Alias = r.Name
+	}
+	r.Spec.Url = normalizeURL(strings.TrimSpace(r.Spec.Url))
+
+	switch req.Operation {
+	case admissionv1.Create:
+		setAnnotation(r, CreatedByAnnotation, req.UserInfo.Username)
+	case admissionv1.Update:
+		// a full replace (kubectl replace, a GitOps PUT) does not carry the annotation, keep the recorded creator
+		if _, ok := r.Annotations[CreatedByAnnotation]; !ok {
+			prev := &Go{}
+			if err := d.decoder.DecodeRaw(req.OldObject, prev); err != nil {
+				return admission.Errored(http.StatusBadRequest, err)
+			}
+			if createdBy, ok := prev.Annotations[CreatedByAnnotation]; ok {
+				setAnnotation(r, CreatedByAnnotation, createdBy)
+			}
+		}
+	}
+
+	marshalled, err := json.Marshal(r)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshalled)
 }
 
-// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+func setAnnotation(r *Go, key, value string) {
+	if r.Annotations == nil {
+		r.Annotations = map[string]string{}
+	}
+	r.Annotations[key] = value
+}
+
+// normalizeURL lowercases the scheme and host of rawURL and converts
+// internationalized hosts to punycode, invalid urls are returned as is
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host, err := idna.Lookup.ToASCII(strings.ToLower(u.Hostname()))
+	if err != nil {
+		return rawURL
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		host = host + ":" + port
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = host
+	return u.String()
+}
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
 //+kubebuilder:webhook:path=/validate-shmila-iaf-v1-go,mutating=false,failurePolicy=fail,sideEffects=None,groups=shmila.iaf,resources=goes,verbs=create;update,versions=v1,name=vgo.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &Go{}
+// goValidator validates Go resources against the other Go resources in the cluster
+type goValidator struct {
+	client client.Reader
+	// clusterNamespace holds the GoLinkPolicies that apply to every namespace and the secrets of retained links
+	clusterNamespace string
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *Go) ValidateCreate() error {
-	return nil
+var _ webhook.CustomValidator = &goValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *goValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	r := obj.(*Go)
+	golog.Info("validate create", "name", r.Name)
+	if err := v.validateAliasAvailable(ctx, r); err != nil {
+		return err
+	} else if err := validateRotationInterval(r); err != nil {
+		return err
+	} else if err := validateSchedule(r); err != nil {
+		return err
+	} else if err := validateTarget(r); err != nil {
+		return err
+	} else if err := v.validateURLSource(ctx, r); err != nil {
+		return err
+	} else if err := validateURLPattern(r); err != nil {
+		return err
+	}
+	return v.validatePolicies(ctx, r)
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *Go) ValidateUpdate(old runtime.Object) error {
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *goValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	r := newObj.(*Go)
 	golog.Info("validate update", "name", r.Name)
-	prev := old.(*Go)
-	if prev.Spec.Alias != r.Spec.Alias {
-		return fmt.Errorf("alias field can not be changed")
-	} else {
+	prev := oldObj.(*Go)
+	// the controller removes its finalizer with a metadata only update, a link that a newer
+	// policy disallows must still be deletable, its PolicyCompliant condition flags it instead
+	if r.DeletionTimestamp != nil {
+		return nil
+	}
+	if prev.Spec.Alias != r.Spec.Alias || !equality.Semantic.DeepEqual(prev.Spec.AdditionalAliases, r.Spec.AdditionalAliases) {
+		if err := v.validateAliasAvailable(ctx, r); err != nil {
+			return err
+		}
+	}
+	if prev.Annotations[CreatedByAnnotation] != r.Annotations[CreatedByAnnotation] {
+		return fmt.Errorf("annotation %s can not be changed", CreatedByAnnotation)
+	} else if err := validateRotationInterval(r); err != nil {
+		return err
+	}
+	// the spec was validated when it was last changed, policies added since then only flag it
+	if equality.Semantic.DeepEqual(prev.Spec, r.Spec) {
 		return nil
 	}
+	if err := validateSchedule(r); err != nil {
+		return err
+	} else if err := validateTarget(r); err != nil {
+		return err
+	} else if err := v.validateURLSource(ctx, r); err != nil {
+		return err
+	} else if err := validateURLPattern(r); err != nil {
+		return err
+	}
+	return v.validatePolicies(ctx, r)
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *Go) ValidateDelete() error {
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *goValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	r := obj.(*Go)
 	golog.Info("validate delete", "name", r.Name)
 
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+// validateAliasAvailable rejects aliases that are repeated, already claimed by another Go resource
+// or retained for a deleted Go resource of another namespace
+func (v *goValidator) validateAliasAvailable(ctx context.Context, r *Go) error {
+	retained := corev1.SecretList{}
+	if err := v.client.List(ctx, &retained, client.InNamespace(v.clusterNamespace), client.HasLabels{RetainedLabel}); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, alias := range r.Aliases() {
+		if seen[alias] {
+			return fmt.Errorf("alias %s is listed more than once", alias)
+		}
+		seen[alias] = true
+
+		goes := GoList{}
+		if err := v.client.List(ctx, &goes, client.MatchingFields{AliasField: alias}); err != nil {
+			return err
+		}
+		for _, other := range goes.Items {
+			if other.Namespace == r.Namespace && other.Name == r.Name {
+				continue
+			}
+			return fmt.Errorf("alias %s is already claimed by %s in namespace %s", alias, other.Name, other.Namespace)
+		}
+		for _, secret := range retained.Items {
+			if namespace := string(secret.Data["resourceNamespace"]); string(secret.Data["alias"]) == alias && namespace != r.Namespace {
+				return fmt.Errorf("alias %s is retained for a deleted Go resource in namespace %s", alias, namespace)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRotationInterval rejects a password rotation annotation that is not a duration
+func validateRotationInterval(r *Go) error {
+	value, ok := r.Annotations[PasswordRotationAnnotation]
+	if !ok {
+		return nil
+	}
+	if interval, err := time.ParseDuration(value); err != nil || interval < 0 {
+		return fmt.Errorf("annotation %s must be a non negative duration such as 720h, got %q", PasswordRotationAnnotation, value)
+	}
+	return nil
+}
+
+// validateSchedule rejects an expiry that is set twice or comes before the activation
+func validateSchedule(r *Go) error {
+	if r.Spec.ExpiresAt != nil && r.Spec.TTL != nil {
+		return fmt.Errorf("only one of expiresAt and ttl can be set")
+	}
+	if r.Spec.TTL != nil && r.Spec.TTL.Duration <= 0 {
+		return fmt.Errorf("ttl must be positive")
+	}
+	// the creation timestamp is not set yet when the resource is created
+	expiresAt := r.Spec.ExpiresAt
+	if r.Spec.TTL != nil && !r.CreationTimestamp.IsZero() {
+		expiresAt = r.ExpiryTime()
+	}
+	if r.Spec.ActiveFrom != nil && expiresAt != nil && !r.Spec.ActiveFrom.Before(expiresAt) {
+		return fmt.Errorf("activeFrom must be before the expiry of the link")
+	}
+	return nil
+}
+
+// validateTarget requires exactly one of url, targetRef, urlFrom and urlTemplate
+func validateTarget(r *Go) error {
+	sources := 0
+	for _, set := range []bool{r.Spec.Url != "", r.Spec.TargetRef != nil, r.Spec.UrlFrom != nil, r.Spec.UrlTemplate != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources == 0 {
+		return fmt.Errorf("one of url, targetRef, urlFrom and urlTemplate must be set")
+	} else if sources > 1 {
+		return fmt.Errorf("only one of url, targetRef, urlFrom and urlTemplate can be set")
+	}
+
+	if from := r.Spec.UrlFrom; from != nil && (from.ConfigMapKeyRef == nil) == (from.SecretKeyRef == nil) {
+		return fmt.Errorf("urlFrom must set exactly one of configMapKeyRef and secretKeyRef")
+	}
+	if len(r.Spec.ValuesFrom) > 0 && r.Spec.UrlTemplate == "" {
+		return fmt.Errorf("valuesFrom can only be set with urlTemplate")
+	}
+	if r.Spec.UrlTemplate != "" {
+		if _, err := template.New("url").Parse(r.Spec.UrlTemplate); err != nil {
+			return fmt.Errorf("invalid urlTemplate: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateURLSource rejects a url read from a Secret that did not opt in to it. A Secret
+// that does not exist yet is left to the controller, it checks the Secret before reading it.
+func (v *goValidator) validateURLSource(ctx context.Context, r *Go) error {
+	if !r.URLFromSecret() {
+		return nil
+	}
+	secret := corev1.Secret{}
+	err := v.client.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: r.Spec.UrlFrom.SecretKeyRef.Name}, &secret)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return allowsURLSource(&secret)
+}
+
+// validateURLPattern rejects malformed placeholders, and a pattern together with pathPassthrough
+func validateURLPattern(r *Go) error {
+	if r.Spec.UrlPattern == "" {
+		return nil
+	} else if r.Spec.PathPassthrough {
+		return fmt.Errorf("only one of urlPattern and pathPassthrough can be set")
+	} else if err := ValidateURL(r.Spec.UrlPattern); err != nil {
+		return err
+	} else if err := ValidateURLPattern(r.Spec.UrlPattern); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validatePolicies rejects links that the GoLinkPolicies of the namespace do not allow, every
+// alias must be allowed and so must the domain of a url pattern. The url of a targetRef is only
+// known to the controller, and so is the url of urlFrom and urlTemplate while their sources do
+// not exist yet, the controller checks those before it publishes the link
+func (v *goValidator) validatePolicies(ctx context.Context, r *Go) error {
+	policies, err := PoliciesFor(ctx, v.client, r.Namespace, v.clusterNamespace)
+	if err != nil {
+		return err
+	}
+	if r.Spec.TargetRef != nil {
+		return r.CheckLinkPolicies(policies, "")
+	}
+	rendered, err := r.RenderURL(ctx, v.client)
+	if err != nil {
+		golog.Info("url is not known yet, skipping its validation", "name", r.Name, "error", err.Error())
+		return r.CheckLinkPolicies(policies, "")
+	}
+	if err := ValidateURL(rendered.URL); err != nil {
+		return err
+	}
+	return r.CheckLinkPolicies(policies, rendered.URL)
+}
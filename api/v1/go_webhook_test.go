@@ -0,0 +1,183 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://Chat.Example.COM/Some/Path?q=A": "https://chat.example.com/Some/Path?q=A",
+		"http://EXAMPLE.com:8080/":               "http://example.com:8080/",
+		"https://בדיקה.com/path":                 "https://xn--5dbedt4e.com/path",
+		"http://[::1]:80/x":                      "http://[::1]:80/x",
+		"not a url":                              "not a url",
+	}
+	for in, want := range tests {
+		if got := normalizeURL(in); got != want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDefaultCreatedBy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &goDefaulter{decoder: decoder}
+
+	raw := func(annotations map[string]string) runtime.RawExtension {
+		r := &Go{Spec: GoSpec{Alias: "docs", Url: "https://docs.example.com"}}
+		r.Name = "docs"
+		r.Annotations = annotations
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtime.RawExtension{Raw: b}
+	}
+	createdBy := map[string]string{CreatedByAnnotation: "alice"}
+
+	tests := map[string]struct {
+		op        admissionv1.Operation
+		object    runtime.RawExtension
+		oldObject runtime.RawExtension
+		want      string
+	}{
+		"create":                  {op: admissionv1.Create, object: raw(nil), want: "bob"},
+		"update keeps annotation": {op: admissionv1.Update, object: raw(createdBy), oldObject: raw(createdBy), want: ""},
+		"replace without annotation": {
+			op: admissionv1.Update, object: raw(nil), oldObject: raw(createdBy), want: "alice",
+		},
+		"replace of a resource without creator": {op: admissionv1.Update, object: raw(nil), oldObject: raw(nil), want: ""},
+	}
+	for name, tt := range tests {
+		resp := d.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: tt.op,
+			Object:    tt.object,
+			OldObject: tt.oldObject,
+			UserInfo:  authenticationv1.UserInfo{Username: "bob"},
+		}})
+		if !resp.Allowed {
+			t.Fatalf("%s: not allowed: %v", name, resp.Result)
+		}
+		if got := patchedCreatedBy(resp.Patches); got != tt.want {
+			t.Errorf("%s: created-by patched to %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+// patchedCreatedBy returns the created-by value set by patches, "" if it is not set
+func patchedCreatedBy(patches []jsonpatch.JsonPatchOperation) string {
+	for _, p := range patches {
+		switch p.Path {
+		case "/metadata/annotations":
+			if m, ok := p.Value.(map[string]interface{}); ok {
+				if v, ok := m[CreatedByAnnotation].(string); ok {
+					return v
+				}
+			}
+		case "/metadata/annotations/shmila.iaf~1created-by":
+			if v, ok := p.Value.(string); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func TestValidateAliasRetained(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	} else if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	retained := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-team-a-docs", Namespace: "go-operator", Labels: map[string]string{RetainedLabel: "true"}},
+		Data:       map[string][]byte{"alias": []byte("docs"), "resourceNamespace": []byte("team-a")},
+	}
+	v := &goValidator{
+		client:           fake.NewClientBuilder().WithScheme(scheme).WithObjects(retained).Build(),
+		clusterNamespace: "go-operator",
+	}
+
+	tests := []struct {
+		namespace string
+		alias     string
+		wantErr   bool
+	}{
+		{namespace: "team-a", alias: "docs"},
+		{namespace: "team-b", alias: "docs", wantErr: true},
+		{namespace: "team-b", alias: "wiki"},
+	}
+	for _, tt := range tests {
+		r := &Go{ObjectMeta: metav1.ObjectMeta{Name: "docs", Namespace: tt.namespace}, Spec: GoSpec{Alias: tt.alias}}
+		if err := v.validateAliasAvailable(context.Background(), r); (err != nil) != tt.wantErr {
+			t.Errorf("alias %s in namespace %s: error %v, want error %t", tt.alias, tt.namespace, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateURLSource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	v := &goValidator{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "link", Namespace: "default", Annotations: map[string]string{URLSourceAnnotation: "true"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "default"}},
+	).Build()}
+
+	tests := []struct {
+		secret  string
+		wantErr bool
+	}{
+		{secret: "link"},
+		{secret: "database", wantErr: true},
+		// the controller checks a secret that is created after the Go resource
+		{secret: "missing"},
+	}
+	for _, tt := range tests {
+		r := &Go{ObjectMeta: metav1.ObjectMeta{Name: "docs", Namespace: "default"}}
+		r.Spec.UrlFrom = &GoURLSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: tt.secret},
+			Key:                  "url",
+		}}
+		if err := v.validateURLSource(context.Background(), r); (err != nil) != tt.wantErr {
+			t.Errorf("secret %s: error %v, want error %t", tt.secret, err, tt.wantErr)
+		}
+	}
+}
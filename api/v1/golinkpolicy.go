@@ -0,0 +1,119 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PoliciesFor returns the policies that apply to the Go resources of namespace: the cluster-wide
+// policies of clusterNamespace and the policies of namespace. A link must be allowed by all of them.
+func PoliciesFor(ctx context.Context, c client.Reader, namespace, clusterNamespace string) ([]GoLinkPolicy, error) {
+	policies := GoLinkPolicyList{}
+	if err := c.List(ctx, &policies, client.InNamespace(clusterNamespace)); err != nil {
+		return nil, err
+	}
+	if namespace == clusterNamespace {
+		return policies.Items, nil
+	}
+	own := GoLinkPolicyList{}
+	if err := c.List(ctx, &own, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	return append(policies.Items, own.Items...), nil
+}
+
+// CheckLinkPolicies returns an error when the policies do not allow one of the aliases of r,
+// or do not allow the link to point to rawURL or its url pattern. rawURL is empty while the
+// url is not known yet. The error does not mention a url that is read from a Secret.
+func (r *Go) CheckLinkPolicies(policies []GoLinkPolicy, rawURL string) error {
+	for _, policy := range policies {
+		for _, alias := range r.Aliases() {
+			if err := policy.Spec.AllowsAlias(alias); err != nil {
+				return fmt.Errorf("policy %s/%s: %w", policy.Namespace, policy.Name, err)
+			}
+		}
+		if rawURL != "" {
+			if err := policy.Spec.AllowsURL(rawURL); err != nil {
+				if r.URLFromSecret() {
+					return fmt.Errorf("policy %s/%s: the url read from secret %s is not allowed", policy.Namespace, policy.Name, r.Spec.UrlFrom.SecretKeyRef.Name)
+				}
+				return fmt.Errorf("policy %s/%s: %w", policy.Namespace, policy.Name, err)
+			}
+		}
+		if r.Spec.UrlPattern != "" {
+			sample, err := samplePatternURL(r.Spec.UrlPattern)
+			if err != nil {
+				return err
+			}
+			if err := policy.Spec.AllowsURL(sample); err != nil {
+				return fmt.Errorf("policy %s/%s: url pattern: %w", policy.Namespace, policy.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// AllowsAlias returns an error when the policy does not allow alias
+func (p *GoLinkPolicySpec) AllowsAlias(alias string) error {
+	if len(p.AllowedAliasPrefixes) > 0 && !hasAnyPrefix(alias, p.AllowedAliasPrefixes) {
+		return fmt.Errorf("alias %s must start with one of %v", alias, p.AllowedAliasPrefixes)
+	}
+	return nil
+}
+
+// AllowsURL returns an error when the policy does not allow links to point to rawURL
+func (p *GoLinkPolicySpec) AllowsURL(rawURL string) error {
+	if len(p.AllowedDomains) > 0 {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		if !domainAllowed(strings.ToLower(u.Hostname()), p.AllowedDomains) {
+			return fmt.Errorf("domain %s is not one of %v", u.Hostname(), p.AllowedDomains)
+		}
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func domainAllowed(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if strings.HasPrefix(domain, "*.") {
+			if strings.HasSuffix(host, domain[1:]) {
+				return true
+			}
+		} else if host == domain {
+			return true
+		}
+	}
+	return false
+}
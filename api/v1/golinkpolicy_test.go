@@ -0,0 +1,83 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import "testing"
+
+func TestGoLinkPolicyAllows(t *testing.T) {
+	policy := GoLinkPolicySpec{
+		AllowedDomains:       []string{"grafana.example.com", "*.team-a.example.com"},
+		AllowedAliasPrefixes: []string{"team-a-"},
+	}
+	tests := []struct {
+		alias, url string
+		allowed    bool
+	}{
+		{"team-a-dashboard", "https://grafana.example.com/d/1", true},
+		{"team-a-wiki", "https://wiki.team-a.example.com", true},
+		{"team-a-wiki", "https://WIKI.Team-A.example.com", true},
+		{"team-a-apex", "https://team-a.example.com", false},
+		{"team-b-dashboard", "https://grafana.example.com/d/1", false},
+		{"team-a-evil", "https://grafana.example.com.evil.io", false},
+	}
+	for _, test := range tests {
+		err := policy.AllowsAlias(test.alias)
+		if err == nil {
+			err = policy.AllowsURL(test.url)
+		}
+		if allowed := err == nil; allowed != test.allowed {
+			t.Errorf("policy allows %q -> %q: %v, want allowed=%v", test.alias, test.url, err, test.allowed)
+		}
+	}
+
+	empty := GoLinkPolicySpec{}
+	if err := empty.AllowsAlias("anything"); err != nil {
+		t.Errorf("empty policy should allow any alias, got %v", err)
+	}
+	if err := empty.AllowsURL("http://anywhere"); err != nil {
+		t.Errorf("empty policy should allow any url, got %v", err)
+	}
+}
+
+func TestCheckLinkPoliciesAdditionalAliases(t *testing.T) {
+	policies := []GoLinkPolicy{{Spec: GoLinkPolicySpec{AllowedAliasPrefixes: []string{"team-a-"}}}}
+	link := &Go{Spec: GoSpec{Alias: "team-a-wiki", AdditionalAliases: []string{"wiki"}}}
+	if err := link.CheckLinkPolicies(policies, "https://wiki.example.com"); err == nil {
+		t.Errorf("additional alias wiki should not be allowed")
+	}
+	link.Spec.AdditionalAliases = []string{"team-a-docs"}
+	if err := link.CheckLinkPolicies(policies, ""); err != nil {
+		t.Errorf("aliases with the allowed prefix should be allowed, got %v", err)
+	}
+}
+
+func TestCheckLinkPoliciesURLPattern(t *testing.T) {
+	policies := []GoLinkPolicy{{Spec: GoLinkPolicySpec{AllowedDomains: []string{"jira.example.com", "*.wiki.example.com"}}}}
+	tests := map[string]bool{
+		"https://jira.example.com/browse/%s":       true,
+		"https://jira.example.com/browse/{issue}":  true,
+		"https://{space}.wiki.example.com/%%/{id}": true,
+		"https://evil.example.com/browse/%s":       false,
+		"https://{host}/%s":                        false,
+	}
+	for pattern, allowed := range tests {
+		link := &Go{Spec: GoSpec{Alias: "jira", UrlPattern: pattern}}
+		if err := link.CheckLinkPolicies(policies, "https://jira.example.com"); (err == nil) != allowed {
+			t.Errorf("CheckLinkPolicies with pattern %q = %v, want allowed=%v", pattern, err, allowed)
+		}
+	}
+}
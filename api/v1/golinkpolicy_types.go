@@ -0,0 +1,61 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defines which links the Go resources of a namespace may create
+type GoLinkPolicySpec struct {
+	// +kubebuilder:validation:Optional
+	// the domains links may point to, "*.example.com" allows any subdomain of example.com
+	// an empty list allows any domain
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the prefixes aliases must start with, e.g.: "team-a-"
+	// an empty list allows any alias
+	AllowedAliasPrefixes []string `json:"allowedAliasPrefixes,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Domains",type="string",JSONPath=".spec.allowedDomains",description="The allowed domains"
+//+kubebuilder:printcolumn:name="Prefixes",type="string",JSONPath=".spec.allowedAliasPrefixes",description="The allowed alias prefixes"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// GoLinkPolicy restricts the Go resources of its namespace.
+// Policies in the operator namespace apply to every namespace, the policies of a namespace only restrict it further.
+type GoLinkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GoLinkPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GoLinkPolicyList contains a list of GoLinkPolicy
+type GoLinkPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GoLinkPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GoLinkPolicy{}, &GoLinkPolicyList{})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// API flavors of link servers
+const (
+	// the link-shortener REST API
+	APIFlavorShmila string = "shmila"
+)
+
+// defines a link-shortener instance that go links can be published to
+type GoLinkServerSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^https?://.*$"
+	// the base url of the link server API
+	Url string `json:"url"`
+
+	// +kubebuilder:validation:Optional
+	// a secret in the controller namespace with the credentials for the link server API:
+	// a bearer "token", a "username" and "password", or a "tls.crt" and "tls.key" client
+	// certificate, and optionally a "ca.crt" bundle to verify the server with
+	AuthSecretRef *corev1.LocalObjectReference `json:"authSecretRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	// timeout of a single request to the link server
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=shmila
+	// +kubebuilder:default=shmila
+	// the API the link server speaks
+	APIFlavor string `json:"apiFlavor,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the link server expands urlPattern and pathPassthrough, links published
+	// to other servers only redirect to their url
+	SupportsURLPatterns bool `json:"supportsURLPatterns,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the server used by Go resources without a serverRef
+	Default bool `json:"default,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url",description="The link server url"
+//+kubebuilder:printcolumn:name="Flavor",type="string",JSONPath=".spec.apiFlavor"
+//+kubebuilder:printcolumn:name="Default",type="boolean",JSONPath=".spec.default"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// GoLinkServer is a link-shortener instance that Go resources can target
+type GoLinkServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GoLinkServerSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GoLinkServerList contains a list of GoLinkServer
+type GoLinkServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GoLinkServer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GoLinkServer{}, &GoLinkServerList{})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of objects that a GoLinkTemplate can generate links for
+const (
+	TemplateKindNamespace   string = "Namespace"
+	TemplateKindDeployment  string = "Deployment"
+	TemplateKindStatefulSet string = "StatefulSet"
+	TemplateKindDaemonSet   string = "DaemonSet"
+	TemplateKindService     string = "Service"
+	TemplateKindIngress     string = "Ingress"
+)
+
+// defines the Go resources generated for every matching object
+type GoLinkTemplateSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Namespace;Deployment;StatefulSet;DaemonSet;Service;Ingress
+	// the kind of objects links are generated for, objects are matched in the namespace
+	// of the template, namespaces are only matched by templates in the operator namespace
+	TargetKind string `json:"targetKind"`
+
+	// +kubebuilder:validation:Optional
+	// the labels of matching objects, all objects of the kind match when it is empty
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// a Go template of the alias, rendered with the .Name, .Namespace, .Labels and
+	// .Annotations of the object, e.g.: "{{ .Name }}-logs"
+	AliasTemplate string `json:"aliasTemplate"`
+
+	// +kubebuilder:validation:Required
+	// a Go template of the url, rendered like the alias template,
+	// e.g.: "https://logs.example.com/?namespace={{ .Namespace }}&app={{ .Name }}"
+	UrlTemplate string `json:"urlTemplate"`
+
+	// +kubebuilder:validation:Optional
+	// the GoLinkServer the generated links are published to
+	ServerRef string `json:"serverRef,omitempty"`
+}
+
+// the observed state of a GoLinkTemplate
+type GoLinkTemplateStatus struct {
+	// +kubebuilder:validation:Optional
+	// the generation of the spec that was last reconciled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// the number of Go resources generated from the template
+	Links int `json:"links"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// the latest observations of the template state
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// TemplateLabel on a generated Go resource holds the name of its GoLinkTemplate
+const TemplateLabel = "shmila.iaf/template"
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Kind",type="string",JSONPath=".spec.targetKind"
+//+kubebuilder:printcolumn:name="Alias",type="string",JSONPath=".spec.aliasTemplate"
+//+kubebuilder:printcolumn:name="Links",type="integer",JSONPath=".status.links"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// GoLinkTemplate generates a Go resource for every object of a kind that matches its selector
+type GoLinkTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GoLinkTemplateSpec   `json:"spec,omitempty"`
+	Status GoLinkTemplateStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GoLinkTemplateList contains a list of GoLinkTemplate
+type GoLinkTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GoLinkTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GoLinkTemplate{}, &GoLinkTemplateList{})
+}
@@ -102,7 +102,7 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	err = (&Go{}).SetupWebhookWithManager(mgr)
+	err = (&Go{}).SetupWebhookWithManager(mgr, "default")
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:webhook
@@ -0,0 +1,136 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+// syncAdditionalAliases pushes the additional aliases of cr to the link server and
+// deletes the ones that were removed from the spec. Each alias has its own password,
+// which is stored in the secret before the alias is created on the link server.
+// A failing alias is reported in its status and does not fail the other aliases,
+// only secret failures are returned.
+func (r *GoReconciler) syncAdditionalAliases(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, sd *secretData, backend golink.GoLinkBackend) error {
+	logger := log.FromContext(ctx)
+	if len(cr.Spec.AdditionalAliases) == 0 && len(sd.AdditionalAliases) == 0 {
+		cr.Status.AdditionalAliases = nil
+		meta.RemoveStatusCondition(&cr.Status.Conditions, shmilav1.ConditionAdditionalAliasesSynced)
+		return nil
+	}
+
+	wanted := map[string]bool{}
+	for _, alias := range cr.Spec.AdditionalAliases {
+		wanted[alias] = true
+	}
+	changed := false
+	for alias, password := range sd.AdditionalAliases {
+		if wanted[alias] {
+			continue
+		}
+		if err := backend.Delete(ctx, alias, password); err != nil {
+			logger.Error(err, "failed to delete removed additional alias", "reason", ReasonLinkDeleteFailed, "additionalAlias", alias)
+			continue
+		}
+		logger.Info("deleted removed additional alias", "additionalAlias", alias)
+		delete(sd.AdditionalAliases, alias)
+		changed = true
+	}
+	for alias := range wanted {
+		if _, ok := sd.AdditionalAliases[alias]; !ok {
+			sd.AdditionalAliases[alias] = randomPassword()
+			changed = true
+		}
+	}
+	if changed {
+		data, err := json.Marshal(sd.AdditionalAliases)
+		if err != nil {
+			return reconcileError(ReasonInternalError, err)
+		}
+		setSecretValue(secret, "additionalAliases", string(data))
+		if err := r.Update(ctx, secret); err != nil {
+			logger.Error(err, "failed to update secret", "reason", ReasonSecretUpdateFailed)
+			return reconcileError(ReasonSecretUpdateFailed, err)
+		}
+	}
+
+	previous := map[string]shmilav1.GoAliasStatus{}
+	for _, status := range cr.Status.AdditionalAliases {
+		previous[status.Alias] = status
+	}
+	statuses := make([]shmilav1.GoAliasStatus, 0, len(cr.Spec.AdditionalAliases))
+	failed := []string{}
+	for _, alias := range cr.Spec.AdditionalAliases {
+		link := r.linkFor(cr, backend, alias, sd.AdditionalAliases[alias])
+		if status, ok := previous[alias]; ok && status.State == Succees && status.LastSyncedURL == cr.Status.ResolvedURL &&
+			status.LastSyncedURLPattern == link.UrlPattern && status.LastSyncedPathPassthrough == link.PathPassthrough {
+			statuses = append(statuses, status)
+			continue
+		}
+		status := r.syncAdditionalAlias(ctx, cr, link, backend)
+		if status.State != Succees {
+			failed = append(failed, alias)
+		}
+		statuses = append(statuses, status)
+	}
+	cr.Status.AdditionalAliases = statuses
+
+	if len(failed) > 0 {
+		setCondition(cr, shmilav1.ConditionAdditionalAliasesSynced, metav1.ConditionFalse, ReasonAliasesFailed,
+			fmt.Sprintf("%d of %d additional aliases failed: %s", len(failed), len(statuses), strings.Join(failed, ", ")))
+	} else {
+		setCondition(cr, shmilav1.ConditionAdditionalAliasesSynced, metav1.ConditionTrue, ReasonAliasesSynced,
+			fmt.Sprintf("%d additional aliases -> %s", len(statuses), cr.Status.ResolvedURL))
+	}
+	return nil
+}
+
+// syncAdditionalAlias pushes the link of a single additional alias and returns its status
+func (r *GoReconciler) syncAdditionalAlias(ctx context.Context, cr *shmilav1.Go, link golink.Link, backend golink.GoLinkBackend) shmilav1.GoAliasStatus {
+	alias := link.Alias
+	logger := log.FromContext(ctx).WithValues("additionalAlias", alias)
+	err := backend.Upsert(ctx, link)
+	if goerrors.Is(err, golink.ErrAliasTaken) {
+		logger.Info("additional alias is already taken", "reason", ReasonAliasTaken)
+		aliasConflictsTotal.WithLabelValues(cr.Namespace).Inc()
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventAliasTaken, "alias %s is already taken on the link server", alias)
+		return shmilav1.GoAliasStatus{Alias: alias, State: Failure, Message: "alias " + alias + " already taken"}
+	} else if err != nil {
+		logger.Error(err, "failed to sync additional alias", "reason", ReasonBackendUnavailable)
+		return shmilav1.GoAliasStatus{Alias: alias, State: Pending, Message: err.Error()}
+	}
+	logger.Info("additional alias synced", "url", cr.Status.ResolvedURL)
+	return shmilav1.GoAliasStatus{
+		Alias:                     alias,
+		State:                     Succees,
+		LastSyncedURL:             cr.Status.ResolvedURL,
+		LastSyncedURLPattern:      link.UrlPattern,
+		LastSyncedPathPassthrough: link.PathPassthrough,
+	}
+}
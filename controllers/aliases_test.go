@@ -0,0 +1,226 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+func TestReconcileAdditionalAliases(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(cr *shmilav1.Go)
+		// patterns makes the link server expand url patterns
+		patterns bool
+		secret   map[string]string
+		links    []golink.Link
+		// wantLinks maps the aliases on the link server to their url pattern
+		wantLinks  map[string]string
+		wantStates map[string]string
+		wantSynced bool
+	}{
+		{
+			name:   "creates added aliases and deletes removed ones",
+			mutate: func(cr *shmilav1.Go) { cr.Spec.AdditionalAliases = []string{"doc", "documents"} },
+			secret: map[string]string{"additionalAliases": `{"documents":"password-documents","wiki":"password-wiki"}`},
+			links: []golink.Link{
+				{Alias: "documents", Url: testURL, Password: "password-documents"},
+				{Alias: "wiki", Url: testURL, Password: "password-wiki"},
+			},
+			wantLinks:  map[string]string{"docs": "", "doc": "", "documents": ""},
+			wantStates: map[string]string{"doc": Succees, "documents": Succees},
+			wantSynced: true,
+		},
+		{
+			name:       "a taken alias does not fail the others",
+			mutate:     func(cr *shmilav1.Go) { cr.Spec.AdditionalAliases = []string{"doc", "wiki"} },
+			links:      []golink.Link{{Alias: "wiki", Url: "https://wiki.example.com", Password: "someone else"}},
+			wantLinks:  map[string]string{"docs": "", "doc": "", "wiki": ""},
+			wantStates: map[string]string{"doc": Succees, "wiki": Failure},
+		},
+		{
+			name: "a changed url pattern is pushed to synced aliases",
+			mutate: func(cr *shmilav1.Go) {
+				cr.Spec.AdditionalAliases = []string{"doc"}
+				cr.Spec.UrlPattern = testURL + "/%s"
+				cr.Status.ResolvedURL = testURL
+				cr.Status.LastSyncedURL = testURL
+				cr.Status.AdditionalAliases = []shmilav1.GoAliasStatus{{Alias: "doc", State: Succees, LastSyncedURL: testURL}}
+			},
+			patterns: true,
+			secret:   map[string]string{"additionalAliases": `{"doc":"password-doc"}`},
+			links: []golink.Link{
+				{Alias: "docs", Url: testURL, Password: "password"},
+				{Alias: "doc", Url: testURL, Password: "password-doc"},
+			},
+			wantLinks:  map[string]string{"docs": testURL + "/%s", "doc": testURL + "/%s"},
+			wantStates: map[string]string{"doc": Succees},
+			wantSynced: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(tt.mutate)
+			backend := newFakeBackend(tt.links...)
+			backend.patterns = tt.patterns
+			r, _ := newTestReconciler(backend, cr, testSecret(cr, tt.secret))
+
+			if err := reconcileGo(t, r, cr); err != nil {
+				t.Fatal(err)
+			}
+			links := map[string]string{}
+			for _, alias := range backend.aliases() {
+				link, _ := backend.link(alias)
+				links[alias] = link.UrlPattern
+			}
+			if !reflect.DeepEqual(links, tt.wantLinks) {
+				t.Errorf("links %v, want %v", links, tt.wantLinks)
+			}
+
+			got := getGo(t, r, cr)
+			states := map[string]string{}
+			for _, status := range got.Status.AdditionalAliases {
+				states[status.Alias] = status.State
+			}
+			if !reflect.DeepEqual(states, tt.wantStates) {
+				t.Errorf("alias states %v, want %v", states, tt.wantStates)
+			}
+			if synced := meta.IsStatusConditionTrue(got.Status.Conditions, shmilav1.ConditionAdditionalAliasesSynced); synced != tt.wantSynced {
+				t.Errorf("%s is %t, want %t", shmilav1.ConditionAdditionalAliasesSynced, synced, tt.wantSynced)
+			}
+
+			// every additional alias on the link server has its password stored in the secret
+			passwords := map[string]string{}
+			if err := json.Unmarshal(getSecret(t, r, cr).Data["additionalAliases"], &passwords); err != nil {
+				t.Fatal(err)
+			}
+			for alias := range tt.wantStates {
+				if link, ok := backend.link(alias); ok && tt.wantStates[alias] == Succees && link.Password != passwords[alias] {
+					t.Errorf("alias %s has password %q on the link server, %q in the secret", alias, link.Password, passwords[alias])
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileRotateAdditionalAliases(t *testing.T) {
+	longAgo := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name             string
+		secret           map[string]string
+		links            []golink.Link
+		noChangePassword bool
+		// wantRotated lists the additional aliases that get a new password
+		wantRotated []string
+		// wantPending lists the additional aliases whose rotation is left to the next reconcile
+		wantPending []string
+		wantErr     bool
+	}{
+		{
+			name: "rotates the additional aliases with the link",
+			secret: map[string]string{
+				"rotatedAt":         longAgo,
+				"additionalAliases": `{"doc":"password-doc","wiki":"password-wiki"}`,
+			},
+			links: []golink.Link{
+				{Alias: "docs", Url: testURL, Password: "password"},
+				{Alias: "doc", Url: testURL, Password: "password-doc"},
+				{Alias: "wiki", Url: "https://wiki.example.com", Password: "someone else"},
+			},
+			wantRotated: []string{"doc"},
+		},
+		{
+			name: "completes an interrupted rotation of an additional alias",
+			secret: map[string]string{
+				"additionalAliases":        `{"doc":"password-doc"}`,
+				"pendingAdditionalAliases": `{"doc":"pending-doc"}`,
+			},
+			links: []golink.Link{
+				{Alias: "docs", Url: testURL, Password: "password"},
+				{Alias: "doc", Url: testURL, Password: "pending-doc"},
+			},
+			wantRotated: []string{"doc"},
+		},
+		{
+			name: "keeps the pending password of an additional alias that failed",
+			secret: map[string]string{
+				"additionalAliases":        `{"doc":"password-doc"}`,
+				"pendingAdditionalAliases": `{"doc":"pending-doc"}`,
+			},
+			links: []golink.Link{
+				{Alias: "docs", Url: testURL, Password: "password"},
+				{Alias: "doc", Url: testURL, Password: "password-doc"},
+			},
+			noChangePassword: true,
+			wantPending:      []string{"doc"},
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) {
+				cr.Annotations = map[string]string{shmilav1.PasswordRotationAnnotation: "24h"}
+				cr.Spec.AdditionalAliases = []string{"doc", "wiki"}
+			})
+			secret := testSecret(cr, tt.secret)
+			before, err := readSecret(secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backend := newFakeBackend(tt.links...)
+			backend.noChangePassword = tt.noChangePassword
+			r, _ := newTestReconciler(backend, cr, secret)
+
+			err = reconcileGo(t, r, cr)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error %v, want error %t", err, tt.wantErr)
+			}
+			after, err := readSecret(getSecret(t, r, cr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rotated := []string{}
+			for alias, old := range before.AdditionalAliases {
+				password := after.AdditionalAliases[alias]
+				if link, ok := backend.link(alias); ok && link.Password == password && password != old {
+					rotated = append(rotated, alias)
+				}
+			}
+			sort.Strings(rotated)
+			if len(rotated)+len(tt.wantRotated) > 0 && !reflect.DeepEqual(rotated, tt.wantRotated) {
+				t.Errorf("rotated %v, want %v", rotated, tt.wantRotated)
+			}
+			pending := []string{}
+			for alias := range after.PendingAdditionalAliases {
+				pending = append(pending, alias)
+			}
+			sort.Strings(pending)
+			if len(pending)+len(tt.wantPending) > 0 && !reflect.DeepEqual(pending, tt.wantPending) {
+				t.Errorf("pending %v, want %v", pending, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+// fakeBackend is an in-memory link server that owns each link by its password,
+// like the link-shortener REST API
+type fakeBackend struct {
+	// patterns makes the backend expand url patterns and pass paths through
+	patterns bool
+	// noGet makes the backend answer like a link server without the get and list endpoints
+	noGet bool
+	// noChangePassword makes the backend answer like a link server without the change password endpoint
+	noChangePassword bool
+
+	mu    sync.Mutex
+	links map[string]golink.Link
+}
+
+var _ golink.GoLinkBackend = &fakeBackend{}
+var _ golink.PatternBackend = &fakeBackend{}
+
+func newFakeBackend(links ...golink.Link) *fakeBackend {
+	b := &fakeBackend{links: map[string]golink.Link{}}
+	for _, link := range links {
+		b.links[link.Alias] = link
+	}
+	return b
+}
+
+func (b *fakeBackend) SupportsPatterns() bool {
+	return b.patterns
+}
+
+func (b *fakeBackend) Upsert(_ context.Context, link golink.Link) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if existing, ok := b.links[link.Alias]; ok && existing.Password != link.Password {
+		return golink.ErrAliasTaken
+	}
+	b.links[link.Alias] = link
+	return nil
+}
+
+func (b *fakeBackend) ChangePassword(_ context.Context, alias, password, newPassword string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	link, ok := b.links[alias]
+	if !ok || b.noChangePassword {
+		return golink.ErrNotFound
+	}
+	if link.Password != password {
+		return golink.ErrAliasTaken
+	}
+	link.Password = newPassword
+	b.links[alias] = link
+	return nil
+}
+
+func (b *fakeBackend) Delete(_ context.Context, alias, password string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	link, ok := b.links[alias]
+	if !ok {
+		return nil
+	}
+	if link.Password != password {
+		return &golink.StatusError{Operation: "delete", StatusCode: http.StatusForbidden}
+	}
+	delete(b.links, alias)
+	return nil
+}
+
+func (b *fakeBackend) Get(_ context.Context, alias string) (*golink.Link, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.noGet {
+		return nil, golink.ErrUnsupported
+	}
+	link, ok := b.links[alias]
+	if !ok {
+		return nil, golink.ErrNotFound
+	}
+	link.Password = ""
+	return &link, nil
+}
+
+func (b *fakeBackend) List(_ context.Context) ([]golink.Link, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.noGet {
+		return nil, golink.ErrUnsupported
+	}
+	links := make([]golink.Link, 0, len(b.links))
+	for _, link := range b.links {
+		link.Password = ""
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Alias < links[j].Alias })
+	return links, nil
+}
+
+// link returns the link of alias including its password
+func (b *fakeBackend) link(alias string) (golink.Link, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	link, ok := b.links[alias]
+	return link, ok
+}
+
+// aliases returns the sorted aliases on the link server
+func (b *fakeBackend) aliases() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	aliases := make([]string, 0, len(b.links))
+	for alias := range b.links {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
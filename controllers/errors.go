@@ -0,0 +1,56 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ReconcileError is a reconcile failure with a stable reason, the reason is
+// used as the condition reason in the status and as the "reason" log field
+type ReconcileError struct {
+	Reason string
+	Err    error
+}
+
+func (e *ReconcileError) Error() string {
+	return e.Reason + ": " + e.Err.Error()
+}
+
+func (e *ReconcileError) Unwrap() error {
+	return e.Err
+}
+
+func reconcileError(reason string, err error) *ReconcileError {
+	return &ReconcileError{Reason: reason, Err: err}
+}
+
+// reasonOf returns the reason of a ReconcileError, or ReasonInternalError for any other error
+func reasonOf(err error) string {
+	var reconcileErr *ReconcileError
+	if errors.As(err, &reconcileErr) {
+		return reconcileErr.Reason
+	}
+	return ReasonInternalError
+}
+
+// isServerGone returns whether err is a failure to get a GoLinkServer that does not exist anymore
+func isServerGone(err error) bool {
+	return reasonOf(err) == ReasonServerNotFound && apierrors.IsNotFound(err)
+}
@@ -17,57 +17,136 @@ limitations under the License.
 package controllers
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"hash/fnv"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
 	"github.com/Guyeise1/go-operator/internal/environment"
+	"github.com/Guyeise1/go-operator/internal/golink"
+	"github.com/Guyeise1/go-operator/internal/probe"
 )
 
 // GoReconciler reconciles a Go object
 type GoReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Servers  *ServerRegistry
+	Recorder record.EventRecorder
+	// Prober probes the urls of links with spec.probe, probing is disabled when it is nil
+	Prober *probe.Prober
+
+	// probes runs the probes of Prober outside of Reconcile
+	probes *probeRunner
+
+	// secretURLs holds the urls read from Secrets by resolveURL, by the key of their
+	// Go resource, since the status only shows them redacted
+	secretURLs sync.Map
 }
 type secretData struct {
 	Alias             string
 	Password          string
 	ResourceName      string
 	ResourceNamespace string
+	Server            string
+	// PendingPassword is the new password of a rotation that was not completed yet
+	PendingPassword string
+	// RotatedAt is when the password was last rotated, in RFC3339
+	RotatedAt string
+	// PreviousAlias is the alias the link was renamed from, it is deleted at PreviousAliasExpiresAt
+	PreviousAlias          string
+	PreviousAliasExpiresAt string
+	// AdditionalAliases maps each additional alias to its password
+	AdditionalAliases map[string]string
+	// PendingAdditionalAliases maps the additional aliases of a rotation that was not completed yet to their new password
+	PendingAdditionalAliases map[string]string
 }
 
-var goHostUrl = environment.GetVariables().GoApiURL
-var secretPrefix = environment.GetVariables().SecretPrefix
+const goFinalizer = "shmila.iaf/finalizer"
+
+// serverRefField indexes Go resources by the GoLinkServer they reference
+const serverRefField = ".spec.serverRef"
+
+// targetRefField indexes Go resources by the kind and name of the object they target
+const targetRefField = ".spec.targetRef"
+
+// urlSourceField indexes Go resources by the kind and name of the ConfigMaps and Secrets their url is read from
+const urlSourceField = ".spec.urlSources"
+
 var complete = ctrl.Result{}
-var retry = ctrl.Result{RequeueAfter: time.Duration(environment.GetVariables().RetryTimeSeconds) * time.Second}
 
-var httpClient = http.Client{
-	Timeout: time.Duration(environment.GetVariables().HttpRequestTimeoutSeconds) * time.Second,
+// secretPrefix, retry and resync read the environment when they are called rather than when
+// the package is initialized, so main sets the logger and reports invalid values first
+func secretPrefix() string {
+	return environment.GetVariables().SecretPrefix
+}
+
+func retry() ctrl.Result {
+	return ctrl.Result{RequeueAfter: time.Duration(environment.GetVariables().RetryTimeSeconds) * time.Second}
+}
+
+func resync() ctrl.Result {
+	return ctrl.Result{RequeueAfter: time.Duration(environment.GetVariables().ResyncIntervalSeconds) * time.Second}
+}
+
+// resyncBefore returns resync, or an earlier requeue when one of the deadlines comes first
+func resyncBefore(deadlines ...*metav1.Time) ctrl.Result {
+	result := resync()
+	for _, deadline := range deadlines {
+		if deadline == nil {
+			continue
+		}
+		if until := time.Until(deadline.Time); until < result.RequeueAfter {
+			result.RequeueAfter = until
+			if result.RequeueAfter < time.Second {
+				result.RequeueAfter = time.Second
+			}
+		}
+	}
+	return result
 }
 
 //+kubebuilder:rbac:groups=shmila.iaf,resources=goes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=shmila.iaf,resources=goes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=shmila.iaf,resources=goes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=shmila.iaf,resources=golinkpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=shmila.iaf,resources=golinkservers,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -79,50 +158,283 @@ var httpClient = http.Client{
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.12.2/pkg/reconcile
 func (r *GoReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
 	result := ctrl.Result{}
 
 	cr := shmilav1.Go{}
 
 	crErr := r.Get(ctx, client.ObjectKey{Name: req.Name, Namespace: req.Namespace}, &cr)
 
-	setStatus(&cr, "go/"+cr.Spec.Alias+" -> "+cr.Spec.Url, Succees)
-
-	defer r.Status().Update(ctx, &cr)
-
-	fmt.Printf("[INFO - Reconcile] reconciling CR: %s-%s\n", cr.Namespace, cr.Name)
-
 	operatorNs := environment.GetVariables().ControllerNamespace
 	secret := getSecretObject(req.Name, req.Namespace, operatorNs)
 
 	secErr := r.Get(ctx, client.ObjectKey{Namespace: secret.Namespace, Name: secret.Name}, &secret)
 
+	logger = logger.WithValues("secret", secret.Name)
+	ctx = log.IntoContext(ctx, logger)
+	logger.V(1).Info("reconciling")
+
 	if errors.IsNotFound(crErr) {
-		fmt.Println("[INFO - reconcile] handle delete for " + req.Name)
-		err := handleDelete(r.Client, ctx, &secret)
+		// the finalizer was removed by hand, the CR is already gone
+		if secErr != nil || isRetained(&secret) {
+			return result, nil
+		}
+		logger.Info("resource is gone, deleting its link")
+		err := r.handleDelete(ctx, &secret)
+		return result, err
+	} else if crErr != nil {
+		logger.Error(crErr, "failed to read resource")
+		return retry(), crErr
+	}
+
+	logger = logger.WithValues("alias", cr.Spec.Alias)
+	ctx = log.IntoContext(ctx, logger)
+
+	if !cr.DeletionTimestamp.IsZero() {
+		return r.handleFinalize(ctx, &cr, &secret, secErr)
+	}
+
+	if !controllerutil.ContainsFinalizer(&cr, goFinalizer) {
+		controllerutil.AddFinalizer(&cr, goFinalizer)
+		if err := r.Update(ctx, &cr); err != nil {
+			logger.Error(err, "failed to add finalizer", "reason", ReasonFinalizerUpdateFailed)
+			return retry(), reconcileError(ReasonFinalizerUpdateFailed, err)
+		}
+	}
+
+	setStatus(&cr, "go/"+cr.Spec.Alias+" -> "+cr.Spec.Url, Succees)
+
+	defer r.updateStatus(ctx, &cr)
+
+	// the schedule comes first, an expired link is deactivated even when its url can not be resolved
+	if result, done, err := r.checkSchedule(ctx, &cr, &secret, secErr); done {
 		return result, err
 	}
 
+	if err := r.resolveURL(ctx, &cr); err != nil {
+		return retry(), err
+	}
+	setStatus(&cr, "go/"+cr.Spec.Alias+" -> "+cr.Status.ResolvedURL, Succees)
+
+	// a link the policies do not allow is not published, what the link server already has is kept
+	if policyErr := r.checkPolicies(ctx, &cr); policyErr != nil {
+		logger.Info("not publishing a link the policies do not allow", "reason", ReasonPolicyViolation)
+		setFailure(&cr, Failure, shmilav1.ConditionSynced, policyErr)
+		return resync(), nil
+	}
+
 	if errors.IsNotFound(secErr) {
-		fmt.Println("[INFO - reconcile] secret " + secret.Name + " Not found, creating...")
+		logger.Info("secret not found, creating")
 		return r.handleCreate(ctx, &cr, &secret)
 	} else if secErr != nil {
-		fmt.Println("[ERROR - reconcile] error reading secret")
-		fmt.Println(secErr)
-		setStatus(&cr, "internal error - ERR_CODE=109", Failure)
-		return retry, secErr
+		err := reconcileError(ReasonSecretReadFailed, secErr)
+		logger.Error(secErr, "failed to read secret", "reason", err.Reason)
+		setFailure(&cr, Failure, shmilav1.ConditionCredentialsReady, err)
+		return retry(), err
 	} else {
-		return r.handleUpdate(&cr, &secret)
+		return r.handleUpdate(ctx, &cr, &secret)
 	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *GoReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	go cleanupLoop(mgr, time.Duration(environment.GetVariables().CleanIntervalSeconds)*time.Second)
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&shmilav1.Go{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
-		Complete(r)
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &shmilav1.Go{}, serverRefField, func(obj client.Object) []string {
+		return []string{obj.(*shmilav1.Go).Spec.ServerRef}
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &shmilav1.Go{}, targetRefField, func(obj client.Object) []string {
+		if ref := obj.(*shmilav1.Go).Spec.TargetRef; ref != nil {
+			return []string{targetKey(ref.Kind, ref.Name)}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &shmilav1.Go{}, urlSourceField, func(obj client.Object) []string {
+		return obj.(*shmilav1.Go).URLSources()
+	}); err != nil {
+		return err
+	}
+	if err := metrics.Registry.Register(&linkInventoryCollector{client: mgr.GetClient()}); err != nil {
+		return err
+	}
+	go r.cleanupLoop(time.Duration(environment.GetVariables().CleanIntervalSeconds) * time.Second)
+	if r.Prober != nil {
+		r.probes = newProbeRunner(r.Prober)
+	}
+	bldr := ctrl.NewControllerManagedBy(mgr).
+		For(&shmilav1.Go{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &shmilav1.GoLinkPolicy{}}, handler.EnqueueRequestsFromMapFunc(r.goesForPolicy)).
+		Watches(&source.Kind{Type: &shmilav1.GoLinkServer{}}, handler.EnqueueRequestsFromMapFunc(r.goesForServer)).
+		Watches(&source.Kind{Type: &corev1.Service{}}, handler.EnqueueRequestsFromMapFunc(r.goesForTarget(shmilav1.TargetKindService))).
+		Watches(&source.Kind{Type: &networkingv1.Ingress{}}, handler.EnqueueRequestsFromMapFunc(r.goesForTarget(shmilav1.TargetKindIngress))).
+		// secrets are not watched, the manager may only get them outside of the controller namespace
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.goesForURLSource("ConfigMap")))
+	// the Gateway API is optional, routes are only watched when its CRDs are installed
+	if _, err := mgr.GetRESTMapper().RESTMapping(httpRouteGVK.GroupKind(), httpRouteGVK.Version); err == nil {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGVK)
+		bldr = bldr.Watches(&source.Kind{Type: route}, handler.EnqueueRequestsFromMapFunc(r.goesForTarget(shmilav1.TargetKindHTTPRoute)))
+	}
+	if r.probes != nil {
+		bldr = bldr.Watches(&source.Channel{Source: r.probes.events}, &handler.EnqueueRequestForObject{})
+	}
+	return bldr.Complete(r)
+}
+
+// goesForPolicy maps a GoLinkPolicy to the Go resources it applies to,
+// a policy in the operator namespace may apply to any namespace
+func (r *GoReconciler) goesForPolicy(obj client.Object) []reconcile.Request {
+	opts := []client.ListOption{}
+	if obj.GetNamespace() != environment.GetVariables().ControllerNamespace {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+	goes := shmilav1.GoList{}
+	if err := r.List(context.TODO(), &goes, opts...); err != nil {
+		ctrl.Log.WithName("policy").Error(err, "failed to list goes", "policy", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(goes.Items))
+	for _, cr := range goes.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cr)})
+	}
+	return requests
+}
+
+// goesForServer maps a GoLinkServer to the Go resources published to it
+func (r *GoReconciler) goesForServer(obj client.Object) []reconcile.Request {
+	refs := []string{obj.GetName()}
+	if obj.(*shmilav1.GoLinkServer).Spec.Default {
+		refs = append(refs, "")
+	}
+	requests := []reconcile.Request{}
+	for _, ref := range refs {
+		goes := shmilav1.GoList{}
+		if err := r.List(context.TODO(), &goes, client.MatchingFields{serverRefField: ref}); err != nil {
+			ctrl.Log.WithName("server").Error(err, "failed to list goes", "server", obj.GetName())
+			return nil
+		}
+		for _, cr := range goes.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cr)})
+		}
+	}
+	return requests
+}
+
+// goesForTarget maps an object of kind to the Go resources that target it
+func (r *GoReconciler) goesForTarget(kind string) handler.MapFunc {
+	return r.goesReferencing(targetRefField, "target", kind)
+}
+
+// goesForURLSource maps a ConfigMap to the Go resources whose url is read from it
+func (r *GoReconciler) goesForURLSource(kind string) handler.MapFunc {
+	return r.goesReferencing(urlSourceField, "url-source", kind)
+}
+
+// goesReferencing maps an object of kind to the Go resources in its namespace that field indexes it for
+func (r *GoReconciler) goesReferencing(field, logName, kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		goes := shmilav1.GoList{}
+		if err := r.List(context.TODO(), &goes,
+			client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{field: targetKey(kind, obj.GetName())},
+		); err != nil {
+			ctrl.Log.WithName(logName).Error(err, "failed to list goes", "kind", kind, "name", obj.GetName())
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(goes.Items))
+		for _, cr := range goes.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cr)})
+		}
+		return requests
+	}
+}
+
+// serverBackend returns the backend of the link server of cr, a link whose
+// server changed is deleted from the previous server before it moves
+func (r *GoReconciler) serverBackend(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, sd *secretData) (golink.GoLinkBackend, error) {
+	logger := log.FromContext(ctx)
+	server, err := r.Servers.Resolve(ctx, cr.Spec.ServerRef)
+	if err != nil {
+		logger.Error(err, "failed to resolve link server", "reason", ReasonServerNotFound)
+		return nil, reconcileError(ReasonServerNotFound, err)
+	}
+	backend, err := r.Servers.Backend(ctx, server)
+	if err != nil {
+		logger.Error(err, "failed to get link server", "reason", ReasonServerNotFound, "server", server)
+		return nil, reconcileError(ReasonServerNotFound, err)
+	}
+
+	if sd.Server != server {
+		logger.Info("link server changed, moving link", "from", sd.Server, "to", server)
+		if err := r.deleteFromPrevious(ctx, cr, sd); err != nil {
+			return nil, err
+		}
+		cr.Status.AdditionalAliases = nil
+		// the previous alias is not moved along with the link
+		sd.PreviousAlias, sd.PreviousAliasExpiresAt = "", ""
+		deleteSecretValue(secret, "previousAlias")
+		deleteSecretValue(secret, "previousAliasExpiresAt")
+		setSecretValue(secret, "server", server)
+		if err := r.Update(ctx, secret); err != nil {
+			logger.Error(err, "failed to update secret", "reason", ReasonSecretUpdateFailed)
+			return nil, reconcileError(ReasonSecretUpdateFailed, err)
+		}
+		sd.Server = server
+		cr.Status.LastSyncedURL = ""
+	}
+	cr.Status.Server = server
+	return backend, nil
+}
+
+// deleteFromPrevious deletes the links of sd from the server they are moving away from.
+// A GoLinkServer that was deleted since has nothing left to delete the links from.
+func (r *GoReconciler) deleteFromPrevious(ctx context.Context, cr *shmilav1.Go, sd *secretData) error {
+	logger := log.FromContext(ctx).WithValues("server", sd.Server)
+	previous, err := r.Servers.Backend(ctx, sd.Server)
+	if err != nil {
+		reconcileErr := reconcileError(ReasonServerNotFound, err)
+		if isServerGone(reconcileErr) {
+			logger.Info("previous link server no longer exists, moving the link without deleting it")
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventServerGone, "the previous link server %s of go/%s no longer exists, the link was not deleted from it", sd.Server, sd.Alias)
+			return nil
+		}
+		logger.Error(err, "failed to get previous link server", "reason", ReasonServerNotFound)
+		return reconcileErr
+	}
+	for _, alias := range []string{sd.Alias, sd.PreviousAlias} {
+		if alias == "" {
+			continue
+		}
+		if err := previous.Delete(ctx, alias, sd.Password); err != nil {
+			logger.Error(err, "failed to delete link from previous server", "reason", ReasonLinkDeleteFailed)
+			return reconcileError(ReasonLinkDeleteFailed, err)
+		}
+	}
+	for alias, password := range sd.AdditionalAliases {
+		if err := previous.Delete(ctx, alias, password); err != nil {
+			logger.Error(err, "failed to delete additional alias from previous server", "reason", ReasonLinkDeleteFailed, "additionalAlias", alias)
+			return reconcileError(ReasonLinkDeleteFailed, err)
+		}
+	}
+	return nil
+}
+
+// checkPolicies flags links that the GoLinkPolicies of their namespace do not allow, and
+// returns the violation since the webhook can not check the url of every link ahead of time
+func (r *GoReconciler) checkPolicies(ctx context.Context, cr *shmilav1.Go) error {
+	policies, err := shmilav1.PoliciesFor(ctx, r.Client, cr.Namespace, environment.GetVariables().ControllerNamespace)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to list policies")
+		setCondition(cr, shmilav1.ConditionPolicyCompliant, metav1.ConditionUnknown, ReasonInternalError, err.Error())
+		return nil
+	} else if err := cr.CheckLinkPolicies(policies, r.targetURL(cr)); err != nil {
+		setCondition(cr, shmilav1.ConditionPolicyCompliant, metav1.ConditionFalse, ReasonPolicyViolation, err.Error())
+		return reconcileError(ReasonPolicyViolation, err)
+	}
+	setCondition(cr, shmilav1.ConditionPolicyCompliant, metav1.ConditionTrue, ReasonPolicyAllowed, "the link is allowed by the policies of its namespace")
+	return nil
 }
 
 func randomPassword() string {
@@ -136,105 +448,561 @@ func randomPassword() string {
 }
 
 func (r *GoReconciler) handleCreate(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret) (ctrl.Result, error) {
-	fmt.Printf("[INFO - handleCreate] starting create process for CR: %s-%s\n", cr.Namespace, cr.Name)
+	logger := log.FromContext(ctx)
+	server, err := r.Servers.Resolve(ctx, cr.Spec.ServerRef)
+	if err != nil {
+		reconcileErr := reconcileError(ReasonServerNotFound, err)
+		logger.Error(err, "failed to resolve link server", "reason", reconcileErr.Reason)
+		setFailure(cr, Failure, shmilav1.ConditionSynced, reconcileErr)
+		return retry(), reconcileErr
+	}
 	data := map[string]string{
 		"alias":             cr.Spec.Alias,
 		"password":          randomPassword(),
 		"resourceName":      cr.Name,
 		"resourceNamespace": cr.Namespace,
+		"server":            server,
+	}
+	retained, err := r.findRetained(ctx, cr)
+	if err != nil {
+		reconcileErr := reconcileError(ReasonSecretReadFailed, err)
+		logger.Error(err, "failed to list retained secrets", "reason", reconcileErr.Reason)
+		setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, reconcileErr)
+		return retry(), reconcileErr
+	}
+	if cr.Spec.AdoptFrom != nil {
+		if data["password"], err = r.adoptedPassword(ctx, cr); err != nil {
+			reconcileErr := reconcileError(ReasonAdoptionFailed, err)
+			logger.Error(err, "failed to read the password of the adopted link", "reason", reconcileErr.Reason)
+			setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, reconcileErr)
+			return retry(), reconcileErr
+		}
+		data["adoptedPassword"] = data["password"]
+	} else if retained != nil {
+		// the link keeps its credentials and server, handleUpdate moves it when the server differs
+		for key, value := range retained.Data {
+			if key != "resourceName" && key != "resourceNamespace" {
+				data[key] = string(value)
+			}
+		}
 	}
 	secret.StringData = data
 	secret.ResourceVersion = ""
-	err1 := r.Create(ctx, secret)
-	if err1 != nil {
-		fmt.Println("[ERROR - handleCreate] failed to create secret", secret.Name)
-		fmt.Println(err1)
-		setStatus(cr, "internal error - ERR_CODE=131", Failure)
-		return retry, fmt.Errorf("internal error - ERR_CODE=131")
+	if err := r.Create(ctx, secret); err != nil {
+		reconcileErr := reconcileError(ReasonSecretCreateFailed, err)
+		logger.Error(err, "failed to create secret", "reason", reconcileErr.Reason)
+		setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, reconcileErr)
+		return retry(), reconcileErr
+	}
+	logger.Info("created secret")
+	if cr.Spec.AdoptFrom != nil {
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventAdopted, "adopted go/%s using the password in secret %s", cr.Spec.Alias, cr.Spec.AdoptFrom.PasswordSecretRef.Name)
+	} else if retained != nil {
+		logger.Info("adopted retained link", "retainedSecret", retained.Name)
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventAdopted, "adopted retained go/%s", cr.Spec.Alias)
 	}
-	return r.handleUpdate(cr, secret)
+	// the new secret supersedes the retained one, also when spec.adoptFrom provided the password
+	if retained != nil {
+		if err := r.Delete(ctx, retained); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "failed to delete adopted retained secret", "reason", ReasonSecretDeleteFailed, "retainedSecret", retained.Name)
+		}
+	}
+	return r.handleUpdate(ctx, cr, secret)
 }
 
-func handleDelete(r client.Client, ctx context.Context, secret *corev1.Secret) error {
-	fmt.Println("[INFO - handleDelete] starting delete process for (secret)", secret.Name)
-	secretData, err := readSecret(secret)
-	if err != nil {
-		fmt.Println(err)
-		return fmt.Errorf("internal error - ERR_CODE=148")
+// retain keeps the link on the link server and labels its secret for adoption by a future Go resource
+func (r *GoReconciler) retain(ctx context.Context, secret *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
 	}
+	secret.Labels[shmilav1.RetainedLabel] = "true"
+	if err := r.Update(ctx, secret); err != nil {
+		logger.Error(err, "failed to label retained secret", "reason", ReasonSecretUpdateFailed)
+		return reconcileError(ReasonSecretUpdateFailed, err)
+	}
+	logger.Info("retained link and secret")
+	return nil
+}
 
-	body := map[string]string{"alias": secretData.Alias, "password": secretData.Password}
-	json, _ := json.Marshal(body)
+// findRetained returns the secret of a retained link with the alias of cr, or nil when there is none.
+// Only links retained in the namespace of cr are adopted, a Go resource in another namespace must
+// not take over their credentials. Aliases are not valid label values so the secrets are matched by their data.
+func (r *GoReconciler) findRetained(ctx context.Context, cr *shmilav1.Go) (*corev1.Secret, error) {
+	secrets := corev1.SecretList{}
+	if err := r.List(ctx, &secrets,
+		client.InNamespace(environment.GetVariables().ControllerNamespace),
+		client.HasLabels{shmilav1.RetainedLabel},
+	); err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		if string(secrets.Items[i].Data["alias"]) == cr.Spec.Alias && string(secrets.Items[i].Data["resourceNamespace"]) == cr.Namespace {
+			return &secrets.Items[i], nil
+		}
+	}
+	return nil, nil
+}
 
-	res, err4 := httpClient.Post(goHostUrl+"/api/v1/go-links/delete", "application/json", bytes.NewBuffer(json))
+func isRetained(secret *corev1.Secret) bool {
+	_, ok := secret.Labels[shmilav1.RetainedLabel]
+	return ok
+}
 
-	if err4 != nil {
-		fmt.Printf("[ERROR - handleDelete] failed delete request (POST) %s, link: %s\n", goHostUrl+"/api/v1/go-links/delete", secretData.Alias)
-		fmt.Println(err)
-		return fmt.Errorf("internal error - ERR_CODE=159")
-	} else if res.StatusCode/100 != 2 && res.StatusCode != 404 {
-		fmt.Printf("[ERROR - handleDelete] failed delete request (POST) %s, link: %s, response status %d\n", goHostUrl+"/api/v1/go-links/delete", secretData.Alias, res.StatusCode)
-		fmt.Println(err)
-		return fmt.Errorf("internal error - ERR_CODE=163")
+// adoptedPassword reads the password of an existing link from the secret referenced by spec.adoptFrom
+func (r *GoReconciler) adoptedPassword(ctx context.Context, cr *shmilav1.Go) (string, error) {
+	ref := cr.Spec.AdoptFrom.PasswordSecretRef
+	secret := corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: cr.Namespace}, &secret); err != nil {
+		return "", err
 	}
+	password, ok := secret.Data[ref.Key]
+	if !ok || len(password) == 0 {
+		return "", fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+	}
+	return string(password), nil
+}
 
-	err = r.Delete(ctx, secret)
+// adopt takes over the password of spec.adoptFrom for a link that already has a secret, such
+// as a link whose alias turned out to be taken. The password is adopted while the alias is not
+// available, or when it differs from the one adopted last, a rotated password is kept otherwise.
+func (r *GoReconciler) adopt(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, sd *secretData) error {
+	logger := log.FromContext(ctx)
+	if cr.Spec.AdoptFrom == nil {
+		return nil
+	}
+	ref := cr.Spec.AdoptFrom.PasswordSecretRef
+	password, err := r.adoptedPassword(ctx, cr)
 	if err != nil {
-		fmt.Println("[ERROR - handleDelete] failed to delete secret", secret.Name)
-		fmt.Println(err)
-		return fmt.Errorf("internal error - ERR_CODE=175")
+		logger.Error(err, "failed to read the password of the adopted link", "reason", ReasonAdoptionFailed)
+		return reconcileError(ReasonAdoptionFailed, err)
+	}
+	aliasTaken := meta.IsStatusConditionFalse(cr.Status.Conditions, shmilav1.ConditionAliasAvailable)
+	if password == sd.Password || (!aliasTaken && password == secretValue(secret, "adoptedPassword")) {
+		setCondition(cr, shmilav1.ConditionCredentialsReady, metav1.ConditionTrue, ReasonSecretReady,
+			"credentials adopted from secret "+ref.Name+" are stored in secret "+secret.Name)
+		return nil
+	}
+
+	setSecretValue(secret, "password", password)
+	setSecretValue(secret, "adoptedPassword", password)
+	deleteSecretValue(secret, "pendingPassword")
+	if err := r.Update(ctx, secret); err != nil {
+		logger.Error(err, "failed to store the adopted password", "reason", ReasonSecretUpdateFailed)
+		return reconcileError(ReasonSecretUpdateFailed, err)
 	}
-	fmt.Println("[INFO - handleDelete] success deleting (secret)", secret.Name)
+	sd.Password = password
+	sd.PendingPassword = ""
+	logger.Info("adopted the password of an existing link", "secret", ref.Name)
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventAdopted, "adopted go/%s using the password in secret %s", sd.Alias, ref.Name)
+	setCondition(cr, shmilav1.ConditionCredentialsReady, metav1.ConditionTrue, ReasonPasswordAdopted,
+		"adopted the password in secret "+ref.Name+", it is stored in secret "+secret.Name)
 	return nil
 }
 
-func (r *GoReconciler) handleUpdate(cr *shmilav1.Go, secret *corev1.Secret) (ctrl.Result, error) {
-	fmt.Printf("[INFO - handleUpdate] starting updating process for %s-%s\n", cr.Name, cr.Namespace)
+func (r *GoReconciler) handleDelete(ctx context.Context, secret *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+	secretData, err := readSecret(secret)
+	if err != nil {
+		logger.Error(err, "failed to read secret", "reason", ReasonSecretReadFailed)
+		return reconcileError(ReasonSecretReadFailed, err)
+	}
+	logger = logger.WithValues("alias", secretData.Alias, "server", secretData.Server)
+
+	backend, err := r.Servers.Backend(ctx, secretData.Server)
+	if err != nil {
+		logger.Error(err, "failed to get link server", "reason", ReasonServerNotFound)
+		return reconcileError(ReasonServerNotFound, err)
+	}
+	err = backend.Delete(ctx, secretData.Alias, secretData.Password)
+	if err != nil && secretData.PendingPassword != "" {
+		// an interrupted rotation may have changed the password on the link server
+		err = backend.Delete(ctx, secretData.Alias, secretData.PendingPassword)
+	}
+	if err != nil {
+		logger.Error(err, "failed to delete link", "reason", ReasonLinkDeleteFailed)
+		return reconcileError(ReasonLinkDeleteFailed, err)
+	}
+	if secretData.PreviousAlias != "" {
+		if err := backend.Delete(ctx, secretData.PreviousAlias, secretData.Password); err != nil {
+			logger.Error(err, "failed to delete previous alias", "reason", ReasonLinkDeleteFailed, "previousAlias", secretData.PreviousAlias)
+			return reconcileError(ReasonLinkDeleteFailed, err)
+		}
+	}
+	for alias, password := range secretData.AdditionalAliases {
+		err := backend.Delete(ctx, alias, password)
+		if pending, ok := secretData.PendingAdditionalAliases[alias]; err != nil && ok {
+			err = backend.Delete(ctx, alias, pending)
+		}
+		if err != nil {
+			logger.Error(err, "failed to delete additional alias", "reason", ReasonLinkDeleteFailed, "additionalAlias", alias)
+			return reconcileError(ReasonLinkDeleteFailed, err)
+		}
+	}
+
+	if err := r.Delete(ctx, secret); err != nil {
+		logger.Error(err, "failed to delete secret", "reason", ReasonSecretDeleteFailed)
+		return reconcileError(ReasonSecretDeleteFailed, err)
+	}
+	logger.Info("deleted link and secret")
+	return nil
+}
+
+// handleFinalize deletes the remote link and the credentials secret before
+// releasing the finalizer of a CR that is being deleted
+func (r *GoReconciler) handleFinalize(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, secErr error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(cr, goFinalizer) {
+		return complete, nil
+	}
+	logger.Info("finalizing")
+
+	if cr.Status.State != Deleting {
+		setStatus(cr, "deleting go/"+cr.Spec.Alias, Deleting)
+		r.updateStatus(ctx, cr)
+	}
+
+	if secErr == nil && cr.Spec.DeletionPolicy == shmilav1.DeletionPolicyRetain {
+		if err := r.retain(ctx, secret); err != nil {
+			return retry(), err
+		}
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventRetained, "retained go/%s on the link server", cr.Spec.Alias)
+	} else if secErr == nil {
+		err := r.handleDelete(ctx, secret)
+		if isServerGone(err) {
+			// the GoLinkServer was deleted, there is no link server left to delete the link from
+			logger.Info("link server no longer exists, releasing the finalizer without deleting the link")
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventServerGone, "the link server of go/%s no longer exists, the link was not deleted from it", cr.Spec.Alias)
+			if err := r.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+				logger.Error(err, "failed to delete secret", "reason", ReasonSecretDeleteFailed)
+				return retry(), reconcileError(ReasonSecretDeleteFailed, err)
+			}
+		} else if err != nil {
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventBackendUnavailable, "failed to delete go/%s: %s", cr.Spec.Alias, err)
+			return retry(), err
+		} else {
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventDeleted, "deleted go/%s", cr.Spec.Alias)
+		}
+	} else if !errors.IsNotFound(secErr) {
+		logger.Error(secErr, "failed to read secret", "reason", ReasonSecretReadFailed)
+		return retry(), reconcileError(ReasonSecretReadFailed, secErr)
+	}
+
+	controllerutil.RemoveFinalizer(cr, goFinalizer)
+	if err := r.Update(ctx, cr); err != nil {
+		logger.Error(err, "failed to remove finalizer", "reason", ReasonFinalizerUpdateFailed)
+		return retry(), reconcileError(ReasonFinalizerUpdateFailed, err)
+	}
+	r.secretURLs.Delete(client.ObjectKeyFromObject(cr))
+	return complete, nil
+}
+
+func (r *GoReconciler) handleUpdate(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
 	sd, err := readSecret(secret)
 
 	if err != nil {
-		fmt.Println("[ERROR - handleUpdate] error reading secret " + secret.Name)
-		fmt.Println(err)
-		setStatus(cr, "internal error - ERR_CODE=187", Failure)
-		return retry, fmt.Errorf("internal error - ERR_CODE=187")
+		reconcileErr := reconcileError(ReasonSecretReadFailed, err)
+		logger.Error(err, "failed to read secret", "reason", reconcileErr.Reason)
+		setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, reconcileErr)
+		return retry(), reconcileErr
+	}
+	if isRetained(secret) {
+		// a Go resource with the same name was created again
+		delete(secret.Labels, shmilav1.RetainedLabel)
+		if err := r.Update(ctx, secret); err != nil {
+			reconcileErr := reconcileError(ReasonSecretUpdateFailed, err)
+			logger.Error(err, "failed to adopt retained secret", "reason", reconcileErr.Reason)
+			setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, reconcileErr)
+			return retry(), reconcileErr
+		}
+		logger.Info("adopted retained link")
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventAdopted, "adopted retained go/%s", sd.Alias)
 	}
-	body := map[string]string{
-		"alias":        sd.Alias,
-		"url":          cr.Spec.Url,
-		"password":     sd.Password,
-		"passwordHint": "managed by go-operator",
+	setCondition(cr, shmilav1.ConditionCredentialsReady, metav1.ConditionTrue, ReasonSecretReady, "credentials are stored in secret "+secret.Name)
+	cr.Status.CredentialsSecretRef = &corev1.SecretReference{Name: secret.Name, Namespace: secret.Namespace}
+	if err := r.adopt(ctx, cr, secret, sd); err != nil {
+		setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, err)
+		return retry(), err
 	}
-	json, _ := json.Marshal(body)
-	res, err := httpClient.Post(goHostUrl+"/api/v1/go-links", "application/json", bytes.NewBuffer(json))
 
+	backend, err := r.serverBackend(ctx, cr, secret, sd)
 	if err != nil {
-		fmt.Println("[ERROR - handleUpdate] error in post " + goHostUrl)
-		fmt.Println(err)
-		setStatus(cr, "go api is unavailable right now", Pending)
-		return retry, fmt.Errorf("internal error - ERR_CODE=196")
-	} else {
-		fmt.Println("[INFO - handleUpdate] success posting link ", sd.Alias)
+		setFailure(cr, Failure, shmilav1.ConditionSynced, err)
+		return retry(), err
+	}
+
+	if err := r.rotatePassword(ctx, cr, secret, sd, backend); err != nil {
+		setFailure(cr, Failure, shmilav1.ConditionCredentialsReady, err)
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventBackendUnavailable, "failed to rotate the password of go/%s: %s", sd.Alias, err)
+		return retry(), err
+	}
+
+	if err := r.renameAlias(ctx, cr, secret, sd, backend); err != nil {
+		return r.syncFailed(ctx, cr, err)
+	}
+
+	if err := r.syncAdditionalAliases(ctx, cr, secret, sd, backend); err != nil {
+		return r.syncFailed(ctx, cr, err)
+	}
+
+	if r.inSync(ctx, cr, backend, r.linkFor(cr, backend, sd.Alias, sd.Password)) {
+		logger.V(1).Info("link is in sync")
+		return r.synced(ctx, cr), nil
+	}
+
+	aliases := []string{sd.Alias}
+	if sd.PreviousAlias != "" {
+		aliases = append(aliases, sd.PreviousAlias)
+	}
+	for _, alias := range aliases {
+		if err := backend.Upsert(ctx, r.linkFor(cr, backend, alias, sd.Password)); err != nil {
+			return r.syncFailed(ctx, cr, err)
+		}
 	}
 
-	defer res.Body.Close()
+	logger.Info("link synced", "url", cr.Status.ResolvedURL)
+	if cr.Status.LastSyncedURL == "" {
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventCreated, "created go/%s -> %s", sd.Alias, cr.Status.ResolvedURL)
+	} else if cr.Status.LastSyncedURL != cr.Status.ResolvedURL {
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventUpdated, "updated go/%s -> %s", sd.Alias, cr.Status.ResolvedURL)
+	}
+	cr.Status.LastSyncedURL = cr.Status.ResolvedURL
+	message := "go/" + sd.Alias + " -> " + cr.Status.ResolvedURL
+	if usesPatterns(cr) && !golink.SupportsPatterns(backend) {
+		logger.Info("the link server does not support url patterns, only the url is published")
+		message += ", the link server does not support urlPattern and pathPassthrough"
+	}
+	setCondition(cr, shmilav1.ConditionAliasAvailable, metav1.ConditionTrue, ReasonAliasOwned, "alias "+sd.Alias+" is owned by this resource")
+	setCondition(cr, shmilav1.ConditionSynced, metav1.ConditionTrue, ReasonLinkSynced, message)
+	return r.synced(ctx, cr), nil
+}
 
-	if res.StatusCode == 403 || res.StatusCode == 401 {
-		fmt.Println("[WARN - handleUpdate] link already exists")
+// nextSync returns when a synced cr is reconciled next, failed additional aliases are retried sooner
+func nextSync(cr *shmilav1.Go, nextProbe *metav1.Time) ctrl.Result {
+	if meta.IsStatusConditionFalse(cr.Status.Conditions, shmilav1.ConditionAdditionalAliasesSynced) {
+		return retry()
+	}
+	deadlines := []*metav1.Time{cr.Status.PreviousAliasExpiresAt, cr.ExpiryTime(), nextProbe}
+	if expiresAt := cr.ExpiryTime(); expiresAt != nil && !cr.Status.ExpiryWarned {
+		deadlines = append(deadlines, &metav1.Time{Time: expiresAt.Add(-expiryWarning)})
+	}
+	return resyncBefore(deadlines...)
+}
+
+// syncFailed records a failure to push the link to the link server in the status of cr
+func (r *GoReconciler) syncFailed(ctx context.Context, cr *shmilav1.Go, err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	var statusErr *golink.StatusError
+	var reconcileErr *ReconcileError
+	if goerrors.Is(err, golink.ErrAliasTaken) {
+		logger.Info("alias is already taken", "reason", ReasonAliasTaken)
 		setStatus(cr, "alias "+cr.Spec.Alias+" already taken", Failure)
-		return retry, nil
+		setCondition(cr, shmilav1.ConditionAliasAvailable, metav1.ConditionFalse, ReasonAliasTaken, "alias "+cr.Spec.Alias+" is owned by someone else on the link server")
+		setCondition(cr, shmilav1.ConditionSynced, metav1.ConditionFalse, ReasonAliasTaken, "alias "+cr.Spec.Alias+" already taken")
+		aliasConflictsTotal.WithLabelValues(cr.Namespace).Inc()
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventAliasTaken, "alias %s is already taken on the link server", cr.Spec.Alias)
+		return retry(), nil
+	} else if goerrors.As(err, &reconcileErr) {
+		setFailure(cr, Failure, shmilav1.ConditionSynced, reconcileErr)
+		return retry(), reconcileErr
+	} else if goerrors.As(err, &statusErr) {
+		reconcileErr := reconcileError(ReasonBackendError, err)
+		logger.Error(err, "link server rejected the link", "reason", reconcileErr.Reason, "statusCode", statusErr.StatusCode)
+		setFailure(cr, Failure, shmilav1.ConditionSynced, reconcileErr)
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventBackendUnavailable, "link server answered with status %d", statusErr.StatusCode)
+		return retry(), reconcileErr
 	}
+	reconcileErr = reconcileError(ReasonBackendUnavailable, err)
+	logger.Error(err, "link server is unavailable", "reason", reconcileErr.Reason)
+	setFailure(cr, Pending, shmilav1.ConditionSynced, reconcileErr)
+	r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventBackendUnavailable, "link server is unavailable: %s", err)
+	return retry(), reconcileErr
+}
 
-	if res.StatusCode/100 != 2 {
-		fmt.Printf("[ERROR - handleUpdate] bad status code for update request for link %s the status is: %d\n", sd.Alias, res.StatusCode)
-		b, err2 := ioutil.ReadAll(res.Body)
-		if err2 == nil {
-			fmt.Println(string(b))
+// rotatePassword changes the password of the link and of its additional aliases once its
+// rotation interval passed. The new passwords are written to the secret as pendingPassword and
+// pendingAdditionalAliases before the link server is called, so a rotation that was interrupted
+// between the two writes is completed by the next reconcile.
+func (r *GoReconciler) rotatePassword(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, sd *secretData, backend golink.GoLinkBackend) error {
+	logger := log.FromContext(ctx)
+	if sd.PendingPassword == "" && len(sd.PendingAdditionalAliases) == 0 {
+		if sd.PreviousAlias != "" {
+			// the previous alias shares the password, rotate once the rename is over
+			return nil
+		}
+		interval := rotationInterval(ctx, cr)
+		last := secret.CreationTimestamp.Time
+		if rotatedAt, err := time.Parse(time.RFC3339, sd.RotatedAt); err == nil {
+			last = rotatedAt
+			cr.Status.LastPasswordRotation = &metav1.Time{Time: rotatedAt}
+		}
+		if interval <= 0 || time.Since(last) < interval {
+			return nil
+		}
+		logger.Info("rotating password", "lastRotation", last)
+		sd.PendingPassword = randomPassword()
+		setSecretValue(secret, "pendingPassword", sd.PendingPassword)
+		for alias := range sd.AdditionalAliases {
+			sd.PendingAdditionalAliases[alias] = randomPassword()
 		}
-		setStatus(cr, "internal error - ERR_CODE=209", Failure)
-		return retry, fmt.Errorf("internal error - ERR_CODE=209")
+		if err := setSecretJSON(secret, "pendingAdditionalAliases", sd.PendingAdditionalAliases); err != nil {
+			return reconcileError(ReasonInternalError, err)
+		}
+		if err := r.Update(ctx, secret); err != nil {
+			logger.Error(err, "failed to store pending password", "reason", ReasonSecretUpdateFailed)
+			return reconcileError(ReasonSecretUpdateFailed, err)
+		}
+	} else {
+		logger.Info("completing interrupted password rotation")
 	}
 
-	return complete, nil
+	if sd.PendingPassword != "" {
+		if err := r.changePassword(ctx, cr, backend, sd.Alias, sd.Password, sd.PendingPassword); err != nil {
+			logger.Error(err, "failed to change password on the link server", "reason", ReasonRotationFailed)
+			return reconcileError(ReasonRotationFailed, err)
+		}
+		setSecretValue(secret, "password", sd.PendingPassword)
+		deleteSecretValue(secret, "pendingPassword")
+	}
+	failed := []string{}
+	var failErr error
+	for alias, pending := range sd.PendingAdditionalAliases {
+		password, ok := sd.AdditionalAliases[alias]
+		if !ok {
+			// the alias was deleted since the rotation started
+			delete(sd.PendingAdditionalAliases, alias)
+			continue
+		}
+		err := r.changePassword(ctx, cr, backend, alias, password, pending)
+		if goerrors.Is(err, golink.ErrAliasTaken) {
+			// neither password owns the alias, it belongs to someone else and has nothing to rotate
+			logger.Info("additional alias is owned by someone else, keeping its password", "additionalAlias", alias)
+		} else if err != nil {
+			logger.Error(err, "failed to change the password of an additional alias", "reason", ReasonRotationFailed, "additionalAlias", alias)
+			failed = append(failed, alias)
+			failErr = err
+			continue
+		} else {
+			sd.AdditionalAliases[alias] = pending
+		}
+		delete(sd.PendingAdditionalAliases, alias)
+	}
+	if err := setSecretJSON(secret, "additionalAliases", sd.AdditionalAliases); err != nil {
+		return reconcileError(ReasonInternalError, err)
+	} else if err := setSecretJSON(secret, "pendingAdditionalAliases", sd.PendingAdditionalAliases); err != nil {
+		return reconcileError(ReasonInternalError, err)
+	}
+	now := metav1.Now()
+	if len(failed) == 0 {
+		setSecretValue(secret, "rotatedAt", now.Format(time.RFC3339))
+	}
+	if err := r.Update(ctx, secret); err != nil {
+		logger.Error(err, "failed to store rotated password", "reason", ReasonSecretUpdateFailed)
+		return reconcileError(ReasonSecretUpdateFailed, err)
+	}
+	if sd.PendingPassword != "" {
+		sd.Password, sd.PendingPassword = sd.PendingPassword, ""
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return reconcileError(ReasonRotationFailed, fmt.Errorf("failed to change the password of additional aliases %s: %w", strings.Join(failed, ", "), failErr))
+	}
+	cr.Status.LastPasswordRotation = &now
+	logger.Info("rotated password")
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventPasswordRotated, "rotated the password of go/%s", sd.Alias)
+	return nil
+}
+
+// changePassword moves alias on the link server from password to pending. It also succeeds
+// when the link server already has the pending password, or has no link with alias at all.
+func (r *GoReconciler) changePassword(ctx context.Context, cr *shmilav1.Go, backend golink.GoLinkBackend, alias, password, pending string) error {
+	err := backend.ChangePassword(ctx, alias, password, pending)
+	if goerrors.Is(err, golink.ErrAliasTaken) {
+		// the link server may already have the pending password, an upsert with it only succeeds if it does
+		return backend.Upsert(ctx, r.linkFor(cr, backend, alias, pending))
+	} else if goerrors.Is(err, golink.ErrNotFound) {
+		// a link server without the change password endpoint answers 404 as well,
+		// the pending password is only taken when the link is really missing
+		if _, getErr := backend.Get(ctx, alias); goerrors.Is(getErr, golink.ErrNotFound) {
+			return nil
+		} else if getErr == nil {
+			return fmt.Errorf("the link server has no link to change the password of, but go/%s exists", alias)
+		} else {
+			return fmt.Errorf("the link server has no link to change the password of, and go/%s can not be read: %w", alias, getErr)
+		}
+	}
+	return err
+}
+
+// rotationInterval returns the password rotation interval of cr, the annotation
+// overrides the operator wide interval and zero means no rotation
+func rotationInterval(ctx context.Context, cr *shmilav1.Go) time.Duration {
+	if value, ok := cr.Annotations[shmilav1.PasswordRotationAnnotation]; ok {
+		interval, err := time.ParseDuration(value)
+		if err == nil {
+			return interval
+		}
+		log.FromContext(ctx).Error(err, "invalid password rotation annotation, using the default interval", "value", value)
+	}
+	return time.Duration(environment.GetVariables().RotationIntervalSeconds) * time.Second
+}
+
+// linkFor returns the link of alias to publish to backend, the url pattern and
+// path passthrough are left out for link servers that do not support them
+func (r *GoReconciler) linkFor(cr *shmilav1.Go, backend golink.GoLinkBackend, alias, password string) golink.Link {
+	link := golink.Link{Alias: alias, Url: r.targetURL(cr), Password: password}
+	if golink.SupportsPatterns(backend) {
+		link.UrlPattern = cr.Spec.UrlPattern
+		link.PathPassthrough = cr.Spec.PathPassthrough
+	}
+	return link
+}
+
+func usesPatterns(cr *shmilav1.Go) bool {
+	return cr.Spec.UrlPattern != "" || cr.Spec.PathPassthrough
+}
+
+// inSync checks the link on the link server against the last synced url,
+// a link that was synced before and changed since then is reported as drifted
+func (r *GoReconciler) inSync(ctx context.Context, cr *shmilav1.Go, backend golink.GoLinkBackend, link golink.Link) bool {
+	logger := log.FromContext(ctx)
+	alias := link.Alias
+	if cr.Status.LastSyncedURL == "" || cr.Status.LastSyncedURL != cr.Status.ResolvedURL {
+		return false
+	}
+
+	remote, err := backend.Get(ctx, alias)
+	if goerrors.Is(err, golink.ErrUnsupported) {
+		// the link can not be read back, it is in sync as far as the operator can tell
+		logger.V(1).Info("the link server does not support drift detection")
+		return true
+	} else if goerrors.Is(err, golink.ErrNotFound) {
+		logger.Info("link is missing on the link server, re-applying", "reason", EventDrifted)
+		driftsTotal.WithLabelValues(cr.Namespace).Inc()
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventDrifted, "go/%s is missing on the link server, re-applying", alias)
+		return false
+	} else if err != nil {
+		logger.V(1).Info("failed to fetch link, re-applying", "error", err.Error())
+		return false
+	}
+
+	if remote.Url != link.Url {
+		driftsTotal.WithLabelValues(cr.Namespace).Inc()
+		if cr.URLFromSecret() {
+			logger.Info("link drifted, re-applying", "reason", EventDrifted)
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventDrifted, "go/%s points elsewhere on the link server, re-applying %s", alias, cr.Status.ResolvedURL)
+			return false
+		}
+		logger.Info("link drifted, re-applying", "reason", EventDrifted, "remoteUrl", remote.Url)
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventDrifted, "go/%s points to %s on the link server, re-applying %s", alias, remote.Url, cr.Status.ResolvedURL)
+		return false
+	}
+	if remote.UrlPattern != link.UrlPattern || remote.PathPassthrough != link.PathPassthrough {
+		logger.Info("url pattern changed, re-applying", "urlPattern", link.UrlPattern, "pathPassthrough", link.PathPassthrough)
+		return false
+	}
+	return true
 }
 
 func getSecretObject(resourceName, namespace, operatorNs string) corev1.Secret {
@@ -245,7 +1013,7 @@ func getSecretObject(resourceName, namespace, operatorNs string) corev1.Secret {
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretPrefix + namespace + "-" + resourceName + "-" + mark,
+			Name:      secretPrefix() + namespace + "-" + resourceName + "-" + mark,
 			Namespace: operatorNs,
 		},
 	}
@@ -257,79 +1025,143 @@ func hash(s string) string {
 	return strconv.FormatInt(int64(h.Sum32())/1000, 10)
 }
 
+// setSecretValue sets key in whichever of Data and StringData readSecret reads
+func setSecretValue(secret *corev1.Secret, key, value string) {
+	if secret.StringData != nil {
+		secret.StringData[key] = value
+	} else {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key] = []byte(value)
+	}
+}
+
+// deleteSecretValue removes key from both Data and StringData
+func deleteSecretValue(secret *corev1.Secret, key string) {
+	delete(secret.StringData, key)
+	delete(secret.Data, key)
+}
+
 func readSecret(secret *corev1.Secret) (*secretData, error) {
+	sd, err := readSecretFields(secret)
+	if err != nil {
+		return nil, err
+	}
+	sd.AdditionalAliases = map[string]string{}
+	if value := secretValue(secret, "additionalAliases"); value != "" {
+		if err := json.Unmarshal([]byte(value), &sd.AdditionalAliases); err != nil {
+			return nil, fmt.Errorf("invalid additionalAliases in secret %s: %w", secret.Name, err)
+		}
+	}
+	sd.PendingAdditionalAliases = map[string]string{}
+	if value := secretValue(secret, "pendingAdditionalAliases"); value != "" {
+		if err := json.Unmarshal([]byte(value), &sd.PendingAdditionalAliases); err != nil {
+			return nil, fmt.Errorf("invalid pendingAdditionalAliases in secret %s: %w", secret.Name, err)
+		}
+	}
+	return sd, nil
+}
+
+// setSecretJSON stores value as JSON in key of secret, an empty map removes the key
+func setSecretJSON(secret *corev1.Secret, key string, value map[string]string) error {
+	if len(value) == 0 {
+		deleteSecretValue(secret, key)
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	setSecretValue(secret, key, string(data))
+	return nil
+}
+
+// secretValue reads key from whichever of Data and StringData is set
+func secretValue(secret *corev1.Secret, key string) string {
+	if secret.StringData != nil {
+		return secret.StringData[key]
+	}
+	return string(secret.Data[key])
+}
+
+func readSecretFields(secret *corev1.Secret) (*secretData, error) {
 	if secret.StringData != nil {
 		return &secretData{
-			Alias:             secret.StringData["alias"],
-			Password:          secret.StringData["password"],
-			ResourceName:      secret.StringData["resourceName"],
-			ResourceNamespace: secret.StringData["resourceNamespace"],
+			Alias:                  secret.StringData["alias"],
+			Password:               secret.StringData["password"],
+			ResourceName:           secret.StringData["resourceName"],
+			ResourceNamespace:      secret.StringData["resourceNamespace"],
+			Server:                 secret.StringData["server"],
+			PendingPassword:        secret.StringData["pendingPassword"],
+			RotatedAt:              secret.StringData["rotatedAt"],
+			PreviousAlias:          secret.StringData["previousAlias"],
+			PreviousAliasExpiresAt: secret.StringData["previousAliasExpiresAt"],
 		}, nil
 	} else if secret.Data != nil {
 		return &secretData{
-			Alias:             string(secret.Data["alias"]),
-			Password:          string(secret.Data["password"]),
-			ResourceName:      string(secret.Data["resourceName"]),
-			ResourceNamespace: string(secret.Data["resourceNamespace"]),
+			Alias:                  string(secret.Data["alias"]),
+			Password:               string(secret.Data["password"]),
+			ResourceName:           string(secret.Data["resourceName"]),
+			ResourceNamespace:      string(secret.Data["resourceNamespace"]),
+			Server:                 string(secret.Data["server"]),
+			PendingPassword:        string(secret.Data["pendingPassword"]),
+			RotatedAt:              string(secret.Data["rotatedAt"]),
+			PreviousAlias:          string(secret.Data["previousAlias"]),
+			PreviousAliasExpiresAt: string(secret.Data["previousAliasExpiresAt"]),
 		}, nil
 	} else {
 		return nil, fmt.Errorf("both Data and StringData are nil in secret " + secret.Name)
 	}
 }
 
-func cleanupLoop(mgr ctrl.Manager, interval time.Duration) {
-	fmt.Println("[INFO - cleanupLoop] starting cleanup loop")
+// cleanupLoop is a safety net for CRs whose finalizer was removed by hand,
+// it deletes the links of secrets that no longer have a matching CR
+func (r *GoReconciler) cleanupLoop(interval time.Duration) {
+	ctx := log.IntoContext(context.Background(), ctrl.Log.WithName("cleanup"))
+	log.FromContext(ctx).Info("starting cleanup loop", "interval", interval)
 	for {
-		cleanup(mgr)
+		r.cleanup(ctx)
 		time.Sleep(interval)
 	}
 }
 
-func cleanup(mgr ctrl.Manager) {
-	fmt.Println("[INFO - cleanup] starting cleanup process")
+func (r *GoReconciler) cleanup(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("starting cleanup process")
 	secrets := corev1.SecretList{}
-	if err := mgr.GetClient().List(
-		context.TODO(),
+	if err := r.List(
+		ctx,
 		&secrets,
 		&client.ListOptions{Namespace: environment.GetVariables().ControllerNamespace},
 	); err != nil {
-		fmt.Println("[ERROR - cleanup] failed to list secrets")
-		fmt.Println(err)
+		logger.Error(err, "failed to list secrets")
 		return
 	}
 
 	for _, secret := range secrets.Items {
-		if strings.HasPrefix(secret.Name, secretPrefix) {
+		if strings.HasPrefix(secret.Name, secretPrefix()) && !isRetained(&secret) {
+			secretLogger := logger.WithValues("secret", secret.Name)
 			sd, err := readSecret(&secret)
 			if err != nil {
-				fmt.Println("[ERROR - cleanup] failed to read data from secret ", secret)
-				fmt.Println(err)
+				secretLogger.Error(err, "failed to read secret", "reason", ReasonSecretReadFailed)
 			} else {
 				cr := shmilav1.Go{}
-				if err := mgr.GetClient().Get(
-					context.TODO(),
+				if err := r.Get(
+					ctx,
 					client.ObjectKey{
 						Name:      sd.ResourceName,
 						Namespace: sd.ResourceNamespace,
 					},
 					&cr); errors.IsNotFound(err) {
-					handleDelete(mgr.GetClient(), context.TODO(), &secret)
+					secretLogger = secretLogger.WithValues("namespace", sd.ResourceNamespace, "name", sd.ResourceName)
+					secretLogger.Info("found orphan secret")
+					if err := r.handleDelete(log.IntoContext(ctx, secretLogger), &secret); err == nil {
+						orphansRemovedTotal.Inc()
+						r.Recorder.Eventf(&secret, corev1.EventTypeNormal, EventCleanupOrphan, "deleted orphan go/%s of %s/%s", sd.Alias, sd.ResourceNamespace, sd.ResourceName)
+					}
 				}
 			}
 		}
 	}
 }
-
-const (
-	Failure string = "Failure"
-	Succees string = "Active"
-	Pending string = "Pending"
-)
-
-func setStatus(cr *shmilav1.Go, message, state string) {
-	cr.Status = shmilav1.GoStatus{
-		Message:       message,
-		State:         state,
-		ReconcileTime: time.Now().Format(time.RFC3339),
-	}
-}
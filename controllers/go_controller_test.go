@@ -0,0 +1,413 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+func TestReconcileCreate(t *testing.T) {
+	cr := testGo(func(cr *shmilav1.Go) { cr.Finalizers = nil })
+	backend := newFakeBackend()
+	r, recorder := newTestReconciler(backend, cr)
+
+	if err := reconcileGo(t, r, cr); err != nil {
+		t.Fatal(err)
+	}
+	secret := getSecret(t, r, cr)
+	if secret == nil {
+		t.Fatal("secret was not created")
+	}
+	link, ok := backend.link("docs")
+	if !ok || link.Url != testURL || link.Password != secretValue(secret, "password") {
+		t.Errorf("link %+v, want %s with the password of the secret", link, testURL)
+	}
+	got := getGo(t, r, cr)
+	if got.Status.LastSyncedURL != testURL || !meta.IsStatusConditionTrue(got.Status.Conditions, shmilav1.ConditionSynced) {
+		t.Errorf("status %+v, want synced to %s", got.Status, testURL)
+	}
+	if !hasEvent(recorder, EventCreated) {
+		t.Errorf("no %s event", EventCreated)
+	}
+}
+
+func TestReconcileFinalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(cr *shmilav1.Go)
+		secret    map[string]string
+		noSecret  bool
+		links     []golink.Link
+		wantLinks []string
+		// wantSecret is whether the secret is kept, labeled as retained
+		wantSecret bool
+		wantEvent  string
+	}{
+		{
+			name:      "deletes the link and the secret",
+			secret:    map[string]string{"additionalAliases": `{"doc":"password-doc"}`},
+			links:     []golink.Link{{Alias: "docs", Password: "password"}, {Alias: "doc", Password: "password-doc"}, {Alias: "other", Password: "x"}},
+			wantLinks: []string{"other"},
+			wantEvent: EventDeleted,
+		},
+		{
+			name:      "deletes with the pending password of an interrupted rotation",
+			secret:    map[string]string{"pendingPassword": "pending"},
+			links:     []golink.Link{{Alias: "docs", Password: "pending"}},
+			wantLinks: []string{},
+			wantEvent: EventDeleted,
+		},
+		{
+			name:      "deletes the additional aliases of an interrupted rotation",
+			secret:    map[string]string{"additionalAliases": `{"doc":"password-doc"}`, "pendingAdditionalAliases": `{"doc":"pending-doc"}`},
+			links:     []golink.Link{{Alias: "docs", Password: "password"}, {Alias: "doc", Password: "pending-doc"}},
+			wantLinks: []string{},
+			wantEvent: EventDeleted,
+		},
+		{
+			name:      "deletes the previous alias of a rename",
+			secret:    map[string]string{"previousAlias": "doc", "previousAliasExpiresAt": time.Now().Add(time.Hour).Format(time.RFC3339)},
+			links:     []golink.Link{{Alias: "docs", Password: "password"}, {Alias: "doc", Password: "password"}},
+			wantLinks: []string{},
+			wantEvent: EventDeleted,
+		},
+		{
+			name:       "retains the link",
+			mutate:     func(cr *shmilav1.Go) { cr.Spec.DeletionPolicy = shmilav1.DeletionPolicyRetain },
+			links:      []golink.Link{{Alias: "docs", Password: "password"}},
+			wantLinks:  []string{"docs"},
+			wantSecret: true,
+			wantEvent:  EventRetained,
+		},
+		{
+			name:      "releases the finalizer when the link server was deleted",
+			mutate:    func(cr *shmilav1.Go) { cr.Spec.ServerRef = "deleted" },
+			secret:    map[string]string{"server": "deleted"},
+			links:     []golink.Link{{Alias: "docs", Password: "password"}},
+			wantLinks: []string{"docs"},
+			wantEvent: EventServerGone,
+		},
+		{
+			name:      "releases the finalizer without a secret",
+			noSecret:  true,
+			links:     []golink.Link{{Alias: "docs", Password: "password"}},
+			wantLinks: []string{"docs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) {
+				cr.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				if tt.mutate != nil {
+					tt.mutate(cr)
+				}
+			})
+			objs := []client.Object{cr}
+			if !tt.noSecret {
+				objs = append(objs, testSecret(cr, tt.secret))
+			}
+			backend := newFakeBackend(tt.links...)
+			r, recorder := newTestReconciler(backend, objs...)
+
+			if err := reconcileGo(t, r, cr); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Get(context.Background(), client.ObjectKeyFromObject(cr), &shmilav1.Go{}); !errors.IsNotFound(err) {
+				t.Errorf("resource was not released: %v", err)
+			}
+			if got := backend.aliases(); !reflect.DeepEqual(got, tt.wantLinks) {
+				t.Errorf("links %v, want %v", got, tt.wantLinks)
+			}
+			if secret := getSecret(t, r, cr); (secret != nil) != tt.wantSecret {
+				t.Errorf("secret kept %t, want %t", secret != nil, tt.wantSecret)
+			} else if secret != nil && !isRetained(secret) {
+				t.Error("kept secret is not labeled as retained")
+			}
+			if tt.wantEvent != "" && !hasEvent(recorder, tt.wantEvent) {
+				t.Errorf("no %s event", tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestReconcileDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		noGet     bool
+		links     []golink.Link
+		wantURL   string
+		wantDrift bool
+	}{
+		{name: "in sync", links: []golink.Link{{Alias: "docs", Url: testURL, Password: "password"}}, wantURL: testURL},
+		{name: "missing link is re-applied", wantURL: testURL, wantDrift: true},
+		{
+			name:      "changed link is re-applied",
+			links:     []golink.Link{{Alias: "docs", Url: "https://elsewhere.example.com", Password: "password"}},
+			wantURL:   testURL,
+			wantDrift: true,
+		},
+		{name: "link server without get is not reported as drifted", noGet: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) {
+				cr.Status.ResolvedURL = testURL
+				cr.Status.LastSyncedURL = testURL
+			})
+			backend := newFakeBackend(tt.links...)
+			backend.noGet = tt.noGet
+			r, recorder := newTestReconciler(backend, cr, testSecret(cr, nil))
+
+			if err := reconcileGo(t, r, cr); err != nil {
+				t.Fatal(err)
+			}
+			if link, _ := backend.link("docs"); link.Url != tt.wantURL {
+				t.Errorf("link url %q, want %q", link.Url, tt.wantURL)
+			}
+			if drifted := hasEvent(recorder, EventDrifted); drifted != tt.wantDrift {
+				t.Errorf("%s event %t, want %t", EventDrifted, drifted, tt.wantDrift)
+			}
+		})
+	}
+}
+
+func TestReconcileRotation(t *testing.T) {
+	longAgo := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name   string
+		secret map[string]string
+		// interval is the rotation interval annotation
+		interval string
+		// remote is the password of the link on the link server, empty when it is missing
+		remote           string
+		noGet            bool
+		noChangePassword bool
+		// wantPassword is the password in the secret and on the link server, the old one when the rotation fails
+		wantPassword string
+		wantPending  bool
+		wantErr      bool
+	}{
+		{name: "not due yet", secret: map[string]string{"rotatedAt": time.Now().Format(time.RFC3339)}, interval: "24h", remote: "password", wantPassword: "password"},
+		{name: "disabled", secret: map[string]string{"rotatedAt": longAgo}, interval: "0", remote: "password", wantPassword: "password"},
+		{name: "due", secret: map[string]string{"rotatedAt": longAgo}, interval: "24h", remote: "password"},
+		{name: "interrupted, link server has the old password", secret: map[string]string{"pendingPassword": "pending"}, remote: "password", wantPassword: "pending"},
+		{name: "interrupted, link server already has the pending password", secret: map[string]string{"pendingPassword": "pending"}, remote: "pending", wantPassword: "pending"},
+		{name: "interrupted, link is missing", secret: map[string]string{"pendingPassword": "pending"}, wantPassword: "pending"},
+		{
+			name:   "interrupted, alias was taken by someone else",
+			secret: map[string]string{"pendingPassword": "pending"}, remote: "other",
+			wantPassword: "password", wantPending: true, wantErr: true,
+		},
+		{
+			name:   "link server without the change password endpoint",
+			secret: map[string]string{"pendingPassword": "pending"}, remote: "password", noChangePassword: true,
+			wantPassword: "password", wantPending: true, wantErr: true,
+		},
+		{
+			name:   "link server without the change password and get endpoints",
+			secret: map[string]string{"pendingPassword": "pending"}, remote: "password", noChangePassword: true, noGet: true,
+			wantPassword: "password", wantPending: true, wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) {
+				if tt.interval != "" {
+					cr.Annotations = map[string]string{shmilav1.PasswordRotationAnnotation: tt.interval}
+				}
+			})
+			backend := newFakeBackend()
+			backend.noGet, backend.noChangePassword = tt.noGet, tt.noChangePassword
+			if tt.remote != "" {
+				backend.links["docs"] = golink.Link{Alias: "docs", Url: testURL, Password: tt.remote}
+			}
+			r, _ := newTestReconciler(backend, cr, testSecret(cr, tt.secret))
+
+			err := reconcileGo(t, r, cr)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr && reasonOf(err) != ReasonRotationFailed {
+				t.Errorf("error %v, want %s", err, ReasonRotationFailed)
+			}
+			secret := getSecret(t, r, cr)
+			password := secretValue(secret, "password")
+			if tt.wantPassword != "" && password != tt.wantPassword {
+				t.Errorf("secret password %q, want %q", password, tt.wantPassword)
+			} else if tt.wantPassword == "" && (password == "password" || password == "") {
+				t.Errorf("secret password %q, want a new password", password)
+			}
+			if pending := secretValue(secret, "pendingPassword") != ""; pending != tt.wantPending {
+				t.Errorf("pending password kept %t, want %t", pending, tt.wantPending)
+			}
+			if link, _ := backend.link("docs"); !tt.wantErr && link.Password != password {
+				t.Errorf("link password %q, want the password of the secret %q", link.Password, password)
+			}
+		})
+	}
+}
+
+func TestReconcileAdopt(t *testing.T) {
+	tests := []struct {
+		name string
+		// secret is the data of the credentials secret, nil when the link is created
+		secret     map[string]string
+		aliasTaken bool
+		// adopted is the password in the adoptFrom secret, empty when it does not exist
+		adopted      string
+		remote       string
+		wantPassword string
+		wantAdopted  bool
+		wantErr      bool
+	}{
+		{name: "creates the link with the adopted password", adopted: "adopted", remote: "adopted", wantPassword: "adopted", wantAdopted: true},
+		{name: "fails without the adopted secret", wantErr: true},
+		{
+			name:   "adopts the password when the alias is taken",
+			secret: map[string]string{}, aliasTaken: true,
+			adopted: "adopted", remote: "adopted", wantPassword: "adopted", wantAdopted: true,
+		},
+		{
+			name:    "keeps a password rotated after the adoption",
+			secret:  map[string]string{"password": "rotated", "adoptedPassword": "adopted"},
+			adopted: "adopted", remote: "rotated", wantPassword: "rotated",
+		},
+		{
+			name:    "adopts a changed password",
+			secret:  map[string]string{"password": "rotated", "adoptedPassword": "adopted"},
+			adopted: "changed", remote: "changed", wantPassword: "changed", wantAdopted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) {
+				cr.Spec.AdoptFrom = &shmilav1.GoAdoptFrom{PasswordSecretRef: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "existing"},
+					Key:                  "password",
+				}}
+				if tt.aliasTaken {
+					meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+						Type: shmilav1.ConditionAliasAvailable, Status: metav1.ConditionFalse, Reason: ReasonAliasTaken,
+					})
+				}
+			})
+			objs := []client.Object{cr}
+			if tt.secret != nil {
+				objs = append(objs, testSecret(cr, tt.secret))
+			}
+			if tt.adopted != "" {
+				objs = append(objs, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: cr.Namespace},
+					Data:       map[string][]byte{"password": []byte(tt.adopted)},
+				})
+			}
+			backend := newFakeBackend()
+			if tt.remote != "" {
+				backend.links["docs"] = golink.Link{Alias: "docs", Url: "https://elsewhere.example.com", Password: tt.remote}
+			}
+			r, recorder := newTestReconciler(backend, objs...)
+
+			err := reconcileGo(t, r, cr)
+			if tt.wantErr {
+				if reasonOf(err) != ReasonAdoptionFailed {
+					t.Errorf("error %v, want %s", err, ReasonAdoptionFailed)
+				}
+				if getSecret(t, r, cr) != nil {
+					t.Error("secret was created without the adopted password")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if password := secretValue(getSecret(t, r, cr), "password"); password != tt.wantPassword {
+				t.Errorf("secret password %q, want %q", password, tt.wantPassword)
+			}
+			if link, _ := backend.link("docs"); link.Url != testURL {
+				t.Errorf("link url %q, want %q", link.Url, testURL)
+			}
+			if adopted := hasEvent(recorder, EventAdopted); adopted != tt.wantAdopted {
+				t.Errorf("%s event %t, want %t", EventAdopted, adopted, tt.wantAdopted)
+			}
+		})
+	}
+}
+
+func TestReconcileAdoptRetained(t *testing.T) {
+	tests := []struct {
+		name string
+		// namespace is the namespace of the deleted Go resource that retained the link
+		namespace    string
+		adoptFrom    bool
+		wantPassword string
+		wantDeleted  bool
+	}{
+		{name: "adopts a link retained in the same namespace", namespace: "default", wantPassword: "retained", wantDeleted: true},
+		{name: "does not adopt a link retained in another namespace", namespace: "team-b"},
+		{name: "adoptFrom supersedes the retained secret", namespace: "default", adoptFrom: true, wantPassword: "adopted", wantDeleted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) {
+				if tt.adoptFrom {
+					cr.Spec.AdoptFrom = &shmilav1.GoAdoptFrom{PasswordSecretRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "existing"},
+						Key:                  "password",
+					}}
+				}
+			})
+			retained := testSecret(testGo(func(old *shmilav1.Go) {
+				old.Name, old.Namespace = "old-docs", tt.namespace
+			}), map[string]string{"password": "retained"})
+			retained.Labels = map[string]string{shmilav1.RetainedLabel: "true"}
+			adopted := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: cr.Namespace},
+				Data:       map[string][]byte{"password": []byte("adopted")},
+			}
+			password := "retained"
+			if tt.adoptFrom {
+				password = "adopted"
+			}
+			backend := newFakeBackend(golink.Link{Alias: "docs", Url: testURL, Password: password})
+			r, _ := newTestReconciler(backend, cr, retained, adopted)
+
+			_ = reconcileGo(t, r, cr)
+			got := secretValue(getSecret(t, r, cr), "password")
+			if tt.wantPassword != "" && got != tt.wantPassword {
+				t.Errorf("secret password %q, want %q", got, tt.wantPassword)
+			} else if tt.wantPassword == "" && got == "retained" {
+				t.Error("took over the credentials retained in another namespace")
+			}
+			err := r.Get(context.Background(), client.ObjectKeyFromObject(retained), &corev1.Secret{})
+			if deleted := errors.IsNotFound(err); deleted != tt.wantDeleted {
+				t.Errorf("retained secret deleted %t, want %t", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
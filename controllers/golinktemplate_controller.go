@@ -0,0 +1,286 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/environment"
+)
+
+// templateKinds maps the target kinds of GoLinkTemplates to their group version kind
+var templateKinds = map[string]schema.GroupVersionKind{
+	shmilav1.TemplateKindNamespace:   {Version: "v1", Kind: "Namespace"},
+	shmilav1.TemplateKindDeployment:  {Group: "apps", Version: "v1", Kind: "Deployment"},
+	shmilav1.TemplateKindStatefulSet: {Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	shmilav1.TemplateKindDaemonSet:   {Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	shmilav1.TemplateKindService:     {Version: "v1", Kind: "Service"},
+	shmilav1.TemplateKindIngress:     {Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+}
+
+// Condition reasons of a GoLinkTemplate
+const (
+	ReasonLinksGenerated  string = "LinksGenerated"
+	ReasonInvalidTemplate string = "InvalidTemplate"
+	ReasonLinksFailed     string = "LinksFailed"
+)
+
+// templateData is what the alias and url templates are rendered with
+type templateData struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// GoLinkTemplateReconciler reconciles a GoLinkTemplate object
+type GoLinkTemplateReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=shmila.iaf,resources=golinktemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=shmila.iaf,resources=golinktemplates/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=shmila.iaf,resources=goes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=namespaces;services,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+
+// Reconcile generates a Go resource for every object that matches the template,
+// and deletes the generated Go resources of objects that no longer match
+func (r *GoLinkTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	tmpl := shmilav1.GoLinkTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, &tmpl); errors.IsNotFound(err) {
+		// the generated Go resources are garbage collected with their owner
+		return complete, nil
+	} else if err != nil {
+		logger.Error(err, "failed to read template")
+		return retry(), err
+	}
+	defer r.updateStatus(ctx, &tmpl)
+
+	aliasTemplate, err := template.New("alias").Option("missingkey=error").Parse(tmpl.Spec.AliasTemplate)
+	if err != nil {
+		setTemplateCondition(&tmpl, metav1.ConditionFalse, ReasonInvalidTemplate, "invalid alias template: "+err.Error())
+		return complete, nil
+	}
+	urlTemplate, err := template.New("url").Option("missingkey=error").Parse(tmpl.Spec.UrlTemplate)
+	if err != nil {
+		setTemplateCondition(&tmpl, metav1.ConditionFalse, ReasonInvalidTemplate, "invalid url template: "+err.Error())
+		return complete, nil
+	}
+
+	if tmpl.Spec.TargetKind == shmilav1.TemplateKindNamespace && tmpl.Namespace != environment.GetVariables().ControllerNamespace {
+		setTemplateCondition(&tmpl, metav1.ConditionFalse, ReasonInvalidTemplate, "namespaces can only be targeted by templates in the operator namespace")
+		return complete, nil
+	}
+
+	objects, err := r.matchingObjects(ctx, &tmpl)
+	if err != nil {
+		logger.Error(err, "failed to list matching objects")
+		setTemplateCondition(&tmpl, metav1.ConditionFalse, ReasonLinksFailed, err.Error())
+		return retry(), err
+	}
+
+	wanted := map[string]bool{}
+	failures := []string{}
+	for _, obj := range objects {
+		data := templateData{Name: obj.Name, Namespace: obj.Namespace, Labels: obj.Labels, Annotations: obj.Annotations}
+		alias, aliasErr := render(aliasTemplate, data)
+		url, urlErr := render(urlTemplate, data)
+		if aliasErr != nil || urlErr != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", obj.Name, firstError(aliasErr, urlErr)))
+			continue
+		}
+
+		cr := shmilav1.Go{ObjectMeta: metav1.ObjectMeta{Name: generatedName(tmpl.Name+"-"+obj.Name, ""), Namespace: tmpl.Namespace}}
+		wanted[cr.Name] = true
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, &cr, func() error {
+			// the names of two templates and their objects may join to the same name
+			if cr.ResourceVersion != "" && !metav1.IsControlledBy(&cr, &tmpl) {
+				return fmt.Errorf("go %s already exists and %w", cr.Name, errNotOwned)
+			}
+			if cr.Labels == nil {
+				cr.Labels = map[string]string{}
+			}
+			cr.Labels[shmilav1.TemplateLabel] = tmpl.Name
+			cr.Spec.Alias = alias
+			cr.Spec.Url = url
+			cr.Spec.ServerRef = tmpl.Spec.ServerRef
+			return controllerutil.SetControllerReference(&tmpl, &cr, r.Scheme)
+		}); err != nil {
+			logger.Error(err, "failed to generate link", "name", cr.Name, "alias", alias)
+			failures = append(failures, fmt.Sprintf("%s: %v", obj.Name, err))
+		}
+	}
+
+	generated := shmilav1.GoList{}
+	if err := r.List(ctx, &generated, client.InNamespace(tmpl.Namespace), client.MatchingLabels{shmilav1.TemplateLabel: tmpl.Name}); err != nil {
+		logger.Error(err, "failed to list generated links")
+		return retry(), err
+	}
+	links := 0
+	for i := range generated.Items {
+		cr := &generated.Items[i]
+		if !metav1.IsControlledBy(cr, &tmpl) {
+			continue
+		}
+		if wanted[cr.Name] {
+			links++
+			continue
+		}
+		if err := r.Delete(ctx, cr); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "failed to delete generated link", "name", cr.Name)
+			failures = append(failures, fmt.Sprintf("%s: %v", cr.Name, err))
+			continue
+		}
+		logger.Info("deleted link of object that no longer matches", "name", cr.Name, "alias", cr.Spec.Alias)
+	}
+	tmpl.Status.Links = links
+
+	if len(failures) > 0 {
+		message := strings.Join(failures, "; ")
+		setTemplateCondition(&tmpl, metav1.ConditionFalse, ReasonLinksFailed, message)
+		r.Recorder.Eventf(&tmpl, corev1.EventTypeWarning, ReasonLinksFailed, "failed to generate %d links: %s", len(failures), message)
+		return retry(), nil
+	}
+	setTemplateCondition(&tmpl, metav1.ConditionTrue, ReasonLinksGenerated, fmt.Sprintf("generated %d links", links))
+	return complete, nil
+}
+
+// matchingObjects lists the metadata of the objects that tmpl generates links for
+func (r *GoLinkTemplateReconciler) matchingObjects(ctx context.Context, tmpl *shmilav1.GoLinkTemplate) ([]metav1.PartialObjectMetadata, error) {
+	gvk, ok := templateKinds[tmpl.Spec.TargetKind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported target kind %s", tmpl.Spec.TargetKind)
+	}
+	opts := []client.ListOption{}
+	if tmpl.Spec.TargetKind != shmilav1.TemplateKindNamespace {
+		opts = append(opts, client.InNamespace(tmpl.Namespace))
+	}
+	if tmpl.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(tmpl.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	} else {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: labels.Everything()})
+	}
+
+	objects := metav1.PartialObjectMetadataList{}
+	objects.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.List(ctx, &objects, opts...); err != nil {
+		return nil, err
+	}
+	return objects.Items, nil
+}
+
+func render(tmpl *template.Template, data templateData) (string, error) {
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setTemplateCondition(tmpl *shmilav1.GoLinkTemplate, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&tmpl.Status.Conditions, metav1.Condition{
+		Type:               shmilav1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: tmpl.Generation,
+	})
+}
+
+func (r *GoLinkTemplateReconciler) updateStatus(ctx context.Context, tmpl *shmilav1.GoLinkTemplate) {
+	tmpl.Status.ObservedGeneration = tmpl.Generation
+	if err := r.Status().Update(ctx, tmpl); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update status")
+	}
+}
+
+// templatesFor maps an object of kind to the templates that may match it
+func (r *GoLinkTemplateReconciler) templatesFor(kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		namespace := obj.GetNamespace()
+		if kind == shmilav1.TemplateKindNamespace {
+			namespace = environment.GetVariables().ControllerNamespace
+		}
+		templates := shmilav1.GoLinkTemplateList{}
+		if err := r.List(context.TODO(), &templates, client.InNamespace(namespace)); err != nil {
+			ctrl.Log.WithName("template").Error(err, "failed to list templates", "kind", kind, "name", obj.GetName())
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for _, tmpl := range templates.Items {
+			if tmpl.Spec.TargetKind == kind {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&tmpl)})
+			}
+		}
+		return requests
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GoLinkTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	bldr := ctrl.NewControllerManagedBy(mgr).
+		For(&shmilav1.GoLinkTemplate{}).
+		Owns(&shmilav1.Go{})
+	// only the metadata of the target objects is used, so only their metadata is cached
+	for kind, gvk := range templateKinds {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		bldr = bldr.Watches(&source.Kind{Type: obj}, handler.EnqueueRequestsFromMapFunc(r.templatesFor(kind)), builder.OnlyMetadata)
+	}
+	return bldr.Complete(r)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+func TestGoLinkTemplateReconcile(t *testing.T) {
+	longName := strings.Repeat("a", 70)
+	testTemplate := func() *shmilav1.GoLinkTemplate {
+		return &shmilav1.GoLinkTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default", UID: types.UID("logs-uid")},
+			Spec: shmilav1.GoLinkTemplateSpec{
+				TargetKind:    shmilav1.TemplateKindService,
+				Selector:      &metav1.LabelSelector{MatchLabels: map[string]string{"logs": "true"}},
+				AliasTemplate: "{{ .Name }}-logs",
+				UrlTemplate:   "https://logs.example.com/?app={{ .Name }}",
+			},
+		}
+	}
+	service := func(name string, matches bool) *corev1.Service {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		if matches {
+			service.Labels = map[string]string{"logs": "true"}
+		}
+		return service
+	}
+	generated := func(name, alias string) *shmilav1.Go {
+		cr := &shmilav1.Go{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{shmilav1.TemplateLabel: "logs"}},
+			Spec:       shmilav1.GoSpec{Alias: alias, Url: testURL},
+		}
+		if err := controllerutil.SetControllerReference(testTemplate(), cr, testScheme); err != nil {
+			t.Fatal(err)
+		}
+		return cr
+	}
+
+	tests := []struct {
+		name string
+		objs []client.Object
+		// wantGoes maps the names of the Go resources to their alias
+		wantGoes  map[string]string
+		wantReady bool
+	}{
+		{
+			name:      "generates a link for every matching object",
+			objs:      []client.Object{service("web", true), service("api", true), service("db", false)},
+			wantGoes:  map[string]string{"logs-web": "web-logs", "logs-api": "api-logs"},
+			wantReady: true,
+		},
+		{
+			name:      "deletes the link of an object that no longer matches",
+			objs:      []client.Object{service("web", false), generated("logs-web", "web-logs")},
+			wantGoes:  map[string]string{},
+			wantReady: true,
+		},
+		{
+			name:     "does not update a Go resource it does not own",
+			objs:     []client.Object{service("web", true), &shmilav1.Go{ObjectMeta: metav1.ObjectMeta{Name: "logs-web", Namespace: "default"}, Spec: shmilav1.GoSpec{Alias: "mine", Url: testURL}}},
+			wantGoes: map[string]string{"logs-web": "mine"},
+		},
+		{
+			name:      "shortens long names",
+			objs:      []client.Object{service(longName, true)},
+			wantGoes:  map[string]string{generatedName("logs-"+longName, ""): longName + "-logs"},
+			wantReady: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := testTemplate()
+			objs := append([]client.Object{tmpl}, tt.objs...)
+			c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objs...).Build()
+			r := &GoLinkTemplateReconciler{Client: c, Scheme: testScheme, Recorder: record.NewFakeRecorder(100)}
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tmpl)}); err != nil {
+				t.Fatal(err)
+			}
+			goes := shmilav1.GoList{}
+			if err := c.List(context.Background(), &goes); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, cr := range goes.Items {
+				got[cr.Name] = cr.Spec.Alias
+				if len(cr.Name) > maxGeneratedNameLength {
+					t.Errorf("go %s is longer than %d characters", cr.Name, maxGeneratedNameLength)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantGoes) {
+				t.Errorf("goes %v, want %v", got, tt.wantGoes)
+			}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(tmpl), tmpl); err != nil {
+				t.Fatal(err)
+			}
+			if ready := meta.IsStatusConditionTrue(tmpl.Status.Conditions, shmilav1.ConditionReady); ready != tt.wantReady {
+				t.Errorf("%s is %t, want %t", shmilav1.ConditionReady, ready, tt.wantReady)
+			}
+		})
+	}
+}
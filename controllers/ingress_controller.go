@@ -0,0 +1,192 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+// GoAliasAnnotation on an Ingress creates a Go resource with the annotation value as its alias
+const GoAliasAnnotation = "shmila.iaf/go-alias"
+
+// ingressGoSuffix is appended to the name of an Ingress to name its Go resource, so it does
+// not take over a Go resource that was created by hand with the name of the Ingress
+const ingressGoSuffix = "-ingress"
+
+// maxGeneratedNameLength keeps generated Go names to a DNS label, so the names of their
+// secrets, which add a prefix and the namespace, stay valid
+const maxGeneratedNameLength = 63
+
+// errNotOwned is returned when a generated Go resource would replace one that was not generated
+var errNotOwned = goerrors.New("is not owned by the controller")
+
+// Event reasons of the ingress controller
+const (
+	EventLinkCreated string = "LinkCreated"
+	EventLinkRemoved string = "LinkRemoved"
+	EventLinkFailed  string = "LinkFailed"
+)
+
+// IngressReconciler creates a Go resource for every Ingress with the go-alias annotation
+type IngressReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=shmila.iaf,resources=goes,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates or updates the Go resource of an annotated Ingress, and deletes
+// it once the annotation is removed. The Go resource is owned by the Ingress so it
+// is garbage collected along with it.
+func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	ingress := networkingv1.Ingress{}
+	if err := r.Get(ctx, req.NamespacedName, &ingress); errors.IsNotFound(err) {
+		return complete, nil
+	} else if err != nil {
+		logger.Error(err, "failed to read ingress")
+		return retry(), err
+	}
+
+	cr, err := r.ingressGo(ctx, &ingress)
+	if err != nil {
+		logger.Error(err, "failed to read link")
+		return retry(), err
+	}
+	alias, ok := ingress.Annotations[GoAliasAnnotation]
+	if !ok || !ingress.DeletionTimestamp.IsZero() {
+		return complete, r.removeLink(ctx, &ingress, cr)
+	}
+	logger = logger.WithValues("alias", alias, "go", cr.Name)
+	ctx = log.IntoContext(ctx, logger)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, cr, func() error {
+		if cr.ResourceVersion != "" && !metav1.IsControlledBy(cr, &ingress) {
+			return fmt.Errorf("go %s already exists and %w", cr.Name, errNotOwned)
+		}
+		cr.Spec.Alias = alias
+		cr.Spec.Url = ""
+		cr.Spec.TargetRef = ingressTarget(&ingress)
+		return controllerutil.SetControllerReference(&ingress, cr, r.Scheme)
+	})
+	if goerrors.Is(err, errNotOwned) {
+		logger.Error(err, "failed to create link")
+		r.Recorder.Eventf(&ingress, corev1.EventTypeWarning, EventLinkFailed, "failed to create go/%s: %s", alias, err)
+		return complete, nil
+	} else if err != nil {
+		logger.Error(err, "failed to create or update link")
+		r.Recorder.Eventf(&ingress, corev1.EventTypeWarning, EventLinkFailed, "failed to create go/%s: %s", alias, err)
+		return retry(), err
+	}
+	if result == controllerutil.OperationResultCreated {
+		logger.Info("created link for ingress")
+		r.Recorder.Eventf(&ingress, corev1.EventTypeNormal, EventLinkCreated, "created go/%s", alias)
+	}
+	return complete, nil
+}
+
+// ingressGo returns the Go resource of ingress, named after the ingress with ingressGoSuffix.
+// A Go resource that was created under the name of the ingress itself keeps that name.
+func (r *IngressReconciler) ingressGo(ctx context.Context, ingress *networkingv1.Ingress) (*shmilav1.Go, error) {
+	cr := shmilav1.Go{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(ingress), &cr); err == nil && metav1.IsControlledBy(&cr, ingress) {
+		return &cr, nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	return &shmilav1.Go{ObjectMeta: metav1.ObjectMeta{
+		Name:      generatedName(ingress.Name, ingressGoSuffix),
+		Namespace: ingress.Namespace,
+	}}, nil
+}
+
+// generatedName returns name followed by suffix. A name that is too long is cut, and a hash
+// of the whole name keeps it apart from the other names that are cut the same way.
+func generatedName(name, suffix string) string {
+	if len(name)+len(suffix) <= maxGeneratedNameLength {
+		return name + suffix
+	}
+	mark := "-" + hash(name+suffix)
+	cut := strings.TrimRight(name[:maxGeneratedNameLength-len(suffix)-len(mark)], "-.")
+	return cut + mark + suffix
+}
+
+// removeLink deletes the Go resource of an ingress whose annotation was removed
+func (r *IngressReconciler) removeLink(ctx context.Context, ingress *networkingv1.Ingress, cr *shmilav1.Go) error {
+	logger := log.FromContext(ctx)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cr), cr); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		logger.Error(err, "failed to read link")
+		return err
+	}
+	if !metav1.IsControlledBy(cr, ingress) {
+		return nil
+	}
+	if err := r.Delete(ctx, cr); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "failed to delete link")
+		return err
+	}
+	logger.Info("deleted link of ingress", "alias", cr.Spec.Alias)
+	r.Recorder.Eventf(ingress, corev1.EventTypeNormal, EventLinkRemoved, "deleted go/%s", cr.Spec.Alias)
+	return nil
+}
+
+// ingressTarget targets the host and path of the first rule of ingress
+func ingressTarget(ingress *networkingv1.Ingress) *shmilav1.GoTargetRef {
+	ref := &shmilav1.GoTargetRef{Kind: shmilav1.TargetKindIngress, Name: ingress.Name}
+	if len(ingress.Spec.Rules) == 0 {
+		return ref
+	}
+	rule := ingress.Spec.Rules[0]
+	ref.Host = rule.Host
+	if rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+		path := rule.HTTP.Paths[0]
+		// implementation specific paths may be regular expressions
+		if path.PathType != nil && *path.PathType != networkingv1.PathTypeImplementationSpecific && path.Path != "/" {
+			ref.Path = path.Path
+		}
+	}
+	return ref
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1.Ingress{}).
+		Owns(&shmilav1.Go{}).
+		Complete(r)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+func TestIngressReconcile(t *testing.T) {
+	annotated := func(alias string) *networkingv1.Ingress {
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: types.UID("web-uid")}}
+		if alias != "" {
+			ingress.Annotations = map[string]string{GoAliasAnnotation: alias}
+		}
+		ingress.Spec.Rules = []networkingv1.IngressRule{{Host: "web.example.com"}}
+		return ingress
+	}
+	// owned returns a Go resource named name that was generated for the web ingress
+	owned := func(name string) *shmilav1.Go {
+		cr := &shmilav1.Go{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       shmilav1.GoSpec{Alias: "old", Url: testURL},
+		}
+		if err := controllerutil.SetControllerReference(annotated(""), cr, testScheme); err != nil {
+			t.Fatal(err)
+		}
+		return cr
+	}
+	byHand := func(name string) *shmilav1.Go {
+		return &shmilav1.Go{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       shmilav1.GoSpec{Alias: "mine", Url: testURL},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ingress *networkingv1.Ingress
+		goes    []client.Object
+		// wantGoes maps the names of the Go resources to their alias
+		wantGoes  map[string]string
+		wantEvent string
+	}{
+		{
+			name:      "creates a link for the annotation",
+			ingress:   annotated("web"),
+			wantGoes:  map[string]string{"web-ingress": "web"},
+			wantEvent: EventLinkCreated,
+		},
+		{
+			name:     "does not take over a Go resource with the name of the ingress",
+			ingress:  annotated("web"),
+			goes:     []client.Object{byHand("web")},
+			wantGoes: map[string]string{"web": "mine", "web-ingress": "web"},
+		},
+		{
+			name:      "does not update a Go resource it does not own",
+			ingress:   annotated("web"),
+			goes:      []client.Object{byHand("web-ingress")},
+			wantGoes:  map[string]string{"web-ingress": "mine"},
+			wantEvent: EventLinkFailed,
+		},
+		{
+			name:     "keeps the name of a Go resource created under the name of the ingress",
+			ingress:  annotated("web"),
+			goes:     []client.Object{owned("web")},
+			wantGoes: map[string]string{"web": "web"},
+		},
+		{
+			name:      "removes the link with the annotation",
+			ingress:   annotated(""),
+			goes:      []client.Object{owned("web-ingress")},
+			wantGoes:  map[string]string{},
+			wantEvent: EventLinkRemoved,
+		},
+		{
+			name:     "does not remove a Go resource it does not own",
+			ingress:  annotated(""),
+			goes:     []client.Object{byHand("web-ingress")},
+			wantGoes: map[string]string{"web-ingress": "mine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := append([]client.Object{tt.ingress}, tt.goes...)
+			c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objs...).Build()
+			recorder := record.NewFakeRecorder(100)
+			r := &IngressReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tt.ingress)}); err != nil {
+				t.Fatal(err)
+			}
+			goes := shmilav1.GoList{}
+			if err := c.List(context.Background(), &goes); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, cr := range goes.Items {
+				got[cr.Name] = cr.Spec.Alias
+				if cr.Spec.Alias == "web" && (cr.Spec.TargetRef == nil || cr.Spec.TargetRef.Host != "web.example.com") {
+					t.Errorf("go %s targets %+v, want the host of the ingress", cr.Name, cr.Spec.TargetRef)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantGoes) {
+				t.Errorf("goes %v, want %v", got, tt.wantGoes)
+			}
+			if tt.wantEvent != "" && !hasEvent(recorder, tt.wantEvent) {
+				t.Errorf("no %s event", tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestGeneratedName(t *testing.T) {
+	long := strings.Repeat("a", 60)
+	if got := generatedName("web", ingressGoSuffix); got != "web-ingress" {
+		t.Errorf("generatedName(web) = %q, want web-ingress", got)
+	}
+	got := generatedName(long, ingressGoSuffix)
+	if len(got) > maxGeneratedNameLength || !strings.HasSuffix(got, ingressGoSuffix) {
+		t.Errorf("generatedName(%q) = %q, want at most %d characters ending with %s", long, got, maxGeneratedNameLength, ingressGoSuffix)
+	}
+	if other := generatedName(long+"b", ingressGoSuffix); other == got {
+		t.Errorf("names that are cut the same way both generate %q", got)
+	}
+}
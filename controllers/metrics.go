@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+var (
+	orphansRemovedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "go_operator_orphans_removed_total",
+			Help: "Number of orphan links removed by the cleanup loop",
+		},
+	)
+	aliasConflictsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "go_operator_alias_conflicts_total",
+			Help: "Number of link writes rejected because the alias is taken",
+		},
+		[]string{"namespace"},
+	)
+	driftsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "go_operator_link_drifts_total",
+			Help: "Number of links found changed or missing on the link server during resync",
+		},
+		[]string{"namespace"},
+	)
+	targetProbesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "go_operator_target_probes_total",
+			Help: "Number of link target probes by namespace and result",
+		},
+		[]string{"namespace", "result"},
+	)
+	managedLinksDesc = prometheus.NewDesc(
+		"go_operator_managed_links",
+		"Number of managed links by state and namespace",
+		[]string{"namespace", "state"},
+		nil,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphansRemovedTotal, aliasConflictsTotal, driftsTotal, targetProbesTotal)
+}
+
+// linkInventoryCollector counts the Go resources in the cache on every scrape
+type linkInventoryCollector struct {
+	client client.Reader
+}
+
+func (c *linkInventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- managedLinksDesc
+}
+
+func (c *linkInventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	goes := shmilav1.GoList{}
+	if err := c.client.List(context.TODO(), &goes); err != nil {
+		ctrl.Log.WithName("metrics").Error(err, "failed to list goes")
+		return
+	}
+
+	type key struct{ namespace, state string }
+	counts := map[key]int{}
+	for _, cr := range goes.Items {
+		counts[key{cr.Namespace, cr.Status.State}]++
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(managedLinksDesc, prometheus.GaugeValue, float64(count), k.namespace, k.state)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+func testPolicy(namespace string, spec shmilav1.GoLinkPolicySpec) *shmilav1.GoLinkPolicy {
+	return &shmilav1.GoLinkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: namespace}, Spec: spec}
+}
+
+func TestReconcilePolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policies      []client.Object
+		wantPublished bool
+	}{
+		{name: "no policies", wantPublished: true},
+		{
+			name:          "allowed by the cluster policy",
+			policies:      []client.Object{testPolicy(testNamespace, shmilav1.GoLinkPolicySpec{AllowedDomains: []string{"*.example.com"}})},
+			wantPublished: true,
+		},
+		{
+			name:     "not allowed by the cluster policy",
+			policies: []client.Object{testPolicy(testNamespace, shmilav1.GoLinkPolicySpec{AllowedDomains: []string{"*.example.org"}})},
+		},
+		{
+			name: "a permissive namespace policy does not lift the cluster policy",
+			policies: []client.Object{
+				testPolicy(testNamespace, shmilav1.GoLinkPolicySpec{AllowedDomains: []string{"*.example.org"}}),
+				testPolicy("default", shmilav1.GoLinkPolicySpec{AllowedDomains: []string{"docs.example.com"}}),
+			},
+		},
+		{
+			name: "a namespace policy restricts further",
+			policies: []client.Object{
+				testPolicy(testNamespace, shmilav1.GoLinkPolicySpec{AllowedDomains: []string{"*.example.com"}}),
+				testPolicy("default", shmilav1.GoLinkPolicySpec{AllowedAliasPrefixes: []string{"team-a-"}}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(nil)
+			backend := newFakeBackend()
+			r, _ := newTestReconciler(backend, append(tt.policies, cr, testSecret(cr, nil))...)
+
+			if err := reconcileGo(t, r, cr); err != nil {
+				t.Fatal(err)
+			}
+			if _, published := backend.link("docs"); published != tt.wantPublished {
+				t.Errorf("published %t, want %t", published, tt.wantPublished)
+			}
+			got := getGo(t, r, cr)
+			if compliant := meta.IsStatusConditionTrue(got.Status.Conditions, shmilav1.ConditionPolicyCompliant); compliant != tt.wantPublished {
+				t.Errorf("%s is %t, want %t", shmilav1.ConditionPolicyCompliant, compliant, tt.wantPublished)
+			}
+			if !tt.wantPublished {
+				if synced := meta.FindStatusCondition(got.Status.Conditions, shmilav1.ConditionSynced); synced == nil || synced.Reason != ReasonPolicyViolation {
+					t.Errorf("%s condition %+v, want reason %s", shmilav1.ConditionSynced, synced, ReasonPolicyViolation)
+				}
+			}
+		})
+	}
+}
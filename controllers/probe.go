@@ -0,0 +1,200 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/probe"
+)
+
+const (
+	// maxProbeTimeout bounds spec.probe.timeoutSeconds, as the CRD does
+	maxProbeTimeout = 30 * time.Second
+	// maxConcurrentProbes is the number of probes that are sent at the same time
+	maxConcurrentProbes = 10
+)
+
+// probeOutcome is the result of a probe that ran in the background
+type probeOutcome struct {
+	// url is the displayed url that was probed, a result for an older url is dropped
+	url    string
+	result *probe.Result
+	err    error
+}
+
+// probeRunner sends probes outside of Reconcile so a slow target does not hold up the
+// other links. A finished probe is stored until the next reconcile of its link takes
+// it, and the link is enqueued through events.
+type probeRunner struct {
+	prober *probe.Prober
+	events chan event.GenericEvent
+	slots  chan struct{}
+
+	mu       sync.Mutex
+	inFlight map[types.NamespacedName]bool
+	outcomes map[types.NamespacedName]probeOutcome
+}
+
+func newProbeRunner(prober *probe.Prober) *probeRunner {
+	return &probeRunner{
+		prober:   prober,
+		events:   make(chan event.GenericEvent),
+		slots:    make(chan struct{}, maxConcurrentProbes),
+		inFlight: map[types.NamespacedName]bool{},
+		outcomes: map[types.NamespacedName]probeOutcome{},
+	}
+}
+
+// start probes targetURL in the background unless a probe of the link is already running
+func (p *probeRunner) start(key types.NamespacedName, targetURL, displayURL string, opts probe.Options) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[key] {
+		return
+	}
+	p.inFlight[key] = true
+	go func() {
+		p.slots <- struct{}{}
+		result, err := p.prober.Probe(context.Background(), targetURL, opts)
+		<-p.slots
+
+		p.mu.Lock()
+		delete(p.inFlight, key)
+		p.outcomes[key] = probeOutcome{url: displayURL, result: result, err: err}
+		p.mu.Unlock()
+		p.events <- event.GenericEvent{Object: &shmilav1.Go{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}}
+	}()
+}
+
+// take returns the finished probe of the link and forgets it
+func (p *probeRunner) take(key types.NamespacedName) (probeOutcome, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	outcome, ok := p.outcomes[key]
+	delete(p.outcomes, key)
+	return outcome, ok
+}
+
+// synced probes the url of a synced link and returns when to reconcile it next
+func (r *GoReconciler) synced(ctx context.Context, cr *shmilav1.Go) ctrl.Result {
+	return nextSync(cr, r.probeTarget(ctx, cr))
+}
+
+// probeTarget records the result of the last probe of cr in the status, and starts a
+// probe once the probe interval passed. It returns when to probe next, later when the
+// host of the url is rate limited, or nil when the url is not probed or a probe is running.
+func (r *GoReconciler) probeTarget(ctx context.Context, cr *shmilav1.Go) *metav1.Time {
+	key := client.ObjectKeyFromObject(cr)
+	if cr.Spec.Probe == nil || r.probes == nil {
+		if r.probes != nil {
+			r.probes.take(key)
+		}
+		cr.Status.LastProbe = nil
+		meta.RemoveStatusCondition(&cr.Status.Conditions, shmilav1.ConditionTargetReachable)
+		return nil
+	}
+
+	if outcome, ok := r.probes.take(key); ok && outcome.url == cr.Status.ResolvedURL {
+		return r.recordProbe(ctx, cr, outcome)
+	}
+	if next := nextProbe(cr); time.Now().Before(next.Time) {
+		return next
+	}
+
+	spec := cr.Spec.Probe
+	timeout := time.Duration(spec.TimeoutSeconds) * time.Second
+	if timeout > maxProbeTimeout {
+		timeout = maxProbeTimeout
+	}
+	r.probes.start(key, r.targetURL(cr), cr.Status.ResolvedURL, probe.Options{
+		Method:              spec.Method,
+		Timeout:             timeout,
+		FollowRedirects:     spec.FollowRedirects,
+		AcceptedStatusCodes: spec.AcceptedStatusCodes,
+	})
+	return nil
+}
+
+// recordProbe records a finished probe in the status and returns when to probe next
+func (r *GoReconciler) recordProbe(ctx context.Context, cr *shmilav1.Go, outcome probeOutcome) *metav1.Time {
+	logger := log.FromContext(ctx)
+	var rateLimited *probe.RateLimitedError
+	if goerrors.As(outcome.err, &rateLimited) {
+		logger.V(1).Info("probe is rate limited", "retryAfter", rateLimited.RetryAfter)
+		return &metav1.Time{Time: time.Now().Add(rateLimited.RetryAfter)}
+	} else if outcome.err != nil {
+		logger.Error(outcome.err, "failed to probe url")
+		setCondition(cr, shmilav1.ConditionTargetReachable, metav1.ConditionUnknown, ReasonInternalError, "the url can not be probed")
+		return &metav1.Time{Time: time.Now().Add(time.Duration(cr.Spec.Probe.IntervalSeconds) * time.Second)}
+	}
+
+	result := outcome.result
+	wasReachable := !meta.IsStatusConditionFalse(cr.Status.Conditions, shmilav1.ConditionTargetReachable)
+	cr.Status.LastProbe = &shmilav1.GoProbeResult{
+		Time:       metav1.Now(),
+		Url:        cr.Status.ResolvedURL,
+		Reachable:  result.Reachable,
+		StatusCode: result.StatusCode,
+	}
+	message := cr.Status.ResolvedURL + " answered with status " + strconv.Itoa(result.StatusCode)
+	if result.Err != nil {
+		// the error of a request names the url and often its host
+		probeErr := result.Err.Error()
+		if cr.URLFromSecret() {
+			probeErr = "the request failed"
+		}
+		cr.Status.LastProbe.Error = probeErr
+		message = cr.Status.ResolvedURL + " is unreachable: " + probeErr
+	}
+
+	if result.Reachable {
+		targetProbesTotal.WithLabelValues(cr.Namespace, "reachable").Inc()
+		setCondition(cr, shmilav1.ConditionTargetReachable, metav1.ConditionTrue, ReasonTargetReachable, message)
+		return nextProbe(cr)
+	}
+	targetProbesTotal.WithLabelValues(cr.Namespace, "broken").Inc()
+	logger.Info("link target is broken", "reason", ReasonBrokenLink, "statusCode", result.StatusCode)
+	setCondition(cr, shmilav1.ConditionTargetReachable, metav1.ConditionFalse, ReasonBrokenLink, message)
+	if wasReachable {
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventBrokenLink, "go/%s is broken: %s", cr.Spec.Alias, message)
+	}
+	return nextProbe(cr)
+}
+
+// nextProbe returns when the url of cr with spec.probe is probed next, a url that was not probed yet is due now
+func nextProbe(cr *shmilav1.Go) *metav1.Time {
+	last := cr.Status.LastProbe
+	if last == nil || last.Url != cr.Status.ResolvedURL {
+		return &metav1.Time{}
+	}
+	return &metav1.Time{Time: last.Time.Add(time.Duration(cr.Spec.Probe.IntervalSeconds) * time.Second)}
+}
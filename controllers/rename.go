@@ -0,0 +1,92 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+// renameAlias moves the link to the alias in the spec after a rename. The previous
+// alias keeps redirecting to the url for spec.renameGracePeriod, and is deleted from
+// the link server by the first reconcile after the grace period is over.
+func (r *GoReconciler) renameAlias(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, sd *secretData, backend golink.GoLinkBackend) error {
+	logger := log.FromContext(ctx)
+	if sd.Alias != cr.Spec.Alias {
+		logger.Info("alias renamed", "from", sd.Alias)
+		if err := backend.Upsert(ctx, r.linkFor(cr, backend, cr.Spec.Alias, sd.Password)); err != nil {
+			return err
+		}
+		// renaming again during a grace period drops the alias of the earlier rename
+		if sd.PreviousAlias != "" && sd.PreviousAlias != cr.Spec.Alias {
+			if err := backend.Delete(ctx, sd.PreviousAlias, sd.Password); err != nil {
+				logger.Error(err, "failed to delete previous alias", "reason", ReasonLinkDeleteFailed, "previousAlias", sd.PreviousAlias)
+				return reconcileError(ReasonLinkDeleteFailed, err)
+			}
+		}
+
+		gracePeriod := time.Duration(0)
+		if cr.Spec.RenameGracePeriod != nil {
+			gracePeriod = cr.Spec.RenameGracePeriod.Duration
+		}
+		sd.PreviousAlias, sd.Alias = sd.Alias, cr.Spec.Alias
+		sd.PreviousAliasExpiresAt = time.Now().Add(gracePeriod).Format(time.RFC3339)
+		setSecretValue(secret, "alias", sd.Alias)
+		setSecretValue(secret, "previousAlias", sd.PreviousAlias)
+		setSecretValue(secret, "previousAliasExpiresAt", sd.PreviousAliasExpiresAt)
+		if err := r.Update(ctx, secret); err != nil {
+			logger.Error(err, "failed to update secret", "reason", ReasonSecretUpdateFailed)
+			return reconcileError(ReasonSecretUpdateFailed, err)
+		}
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventRenamed, "renamed go/%s to go/%s, the previous alias redirects until %s", sd.PreviousAlias, sd.Alias, sd.PreviousAliasExpiresAt)
+	}
+
+	if sd.PreviousAlias == "" {
+		cr.Status.PreviousAlias = ""
+		cr.Status.PreviousAliasExpiresAt = nil
+		return nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, sd.PreviousAliasExpiresAt)
+	if err == nil && time.Now().Before(expiresAt) {
+		cr.Status.PreviousAlias = sd.PreviousAlias
+		cr.Status.PreviousAliasExpiresAt = &metav1.Time{Time: expiresAt}
+		return nil
+	}
+
+	if err := backend.Delete(ctx, sd.PreviousAlias, sd.Password); err != nil {
+		logger.Error(err, "failed to delete previous alias", "reason", ReasonLinkDeleteFailed, "previousAlias", sd.PreviousAlias)
+		return reconcileError(ReasonLinkDeleteFailed, err)
+	}
+	deleteSecretValue(secret, "previousAlias")
+	deleteSecretValue(secret, "previousAliasExpiresAt")
+	if err := r.Update(ctx, secret); err != nil {
+		logger.Error(err, "failed to update secret", "reason", ReasonSecretUpdateFailed)
+		return reconcileError(ReasonSecretUpdateFailed, err)
+	}
+	logger.Info("deleted previous alias", "previousAlias", sd.PreviousAlias)
+	sd.PreviousAlias, sd.PreviousAliasExpiresAt = "", ""
+	cr.Status.PreviousAlias = ""
+	cr.Status.PreviousAliasExpiresAt = nil
+	return nil
+}
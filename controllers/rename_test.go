@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+func TestReconcileRename(t *testing.T) {
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name         string
+		gracePeriod  *metav1.Duration
+		secret       map[string]string
+		links        []golink.Link
+		wantLinks    []string
+		wantPrevious string
+	}{
+		{
+			name:         "previous alias redirects during the grace period",
+			gracePeriod:  &metav1.Duration{Duration: time.Hour},
+			secret:       map[string]string{"alias": "doc"},
+			links:        []golink.Link{{Alias: "doc", Url: testURL, Password: "password"}},
+			wantLinks:    []string{"doc", "docs"},
+			wantPrevious: "doc",
+		},
+		{
+			name:      "previous alias is deleted without a grace period",
+			secret:    map[string]string{"alias": "doc"},
+			links:     []golink.Link{{Alias: "doc", Url: testURL, Password: "password"}},
+			wantLinks: []string{"docs"},
+		},
+		{
+			name:      "previous alias is deleted once the grace period is over",
+			secret:    map[string]string{"previousAlias": "doc", "previousAliasExpiresAt": past},
+			links:     []golink.Link{{Alias: "docs", Url: testURL, Password: "password"}, {Alias: "doc", Url: testURL, Password: "password"}},
+			wantLinks: []string{"docs"},
+		},
+		{
+			name:         "renaming again drops the alias of the earlier rename",
+			gracePeriod:  &metav1.Duration{Duration: time.Hour},
+			secret:       map[string]string{"alias": "documents", "previousAlias": "doc", "previousAliasExpiresAt": future},
+			links:        []golink.Link{{Alias: "documents", Url: testURL, Password: "password"}, {Alias: "doc", Url: testURL, Password: "password"}},
+			wantLinks:    []string{"docs", "documents"},
+			wantPrevious: "documents",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(func(cr *shmilav1.Go) { cr.Spec.RenameGracePeriod = tt.gracePeriod })
+			backend := newFakeBackend(tt.links...)
+			r, _ := newTestReconciler(backend, cr, testSecret(cr, tt.secret))
+
+			if err := reconcileGo(t, r, cr); err != nil {
+				t.Fatal(err)
+			}
+			if got := backend.aliases(); !reflect.DeepEqual(got, tt.wantLinks) {
+				t.Errorf("links %v, want %v", got, tt.wantLinks)
+			}
+			secret := getSecret(t, r, cr)
+			if got := secretValue(secret, "alias"); got != "docs" {
+				t.Errorf("secret alias %q, want docs", got)
+			}
+			if got := secretValue(secret, "previousAlias"); got != tt.wantPrevious {
+				t.Errorf("secret previous alias %q, want %q", got, tt.wantPrevious)
+			}
+			if got := getGo(t, r, cr).Status.PreviousAlias; got != tt.wantPrevious {
+				t.Errorf("status previous alias %q, want %q", got, tt.wantPrevious)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+// expiryWarning is how long before the expiry of a link a warning event is emitted
+const expiryWarning = 24 * time.Hour
+
+// checkSchedule holds links outside of their activation window. A link that is not
+// active yet, or already expired, is removed from the link server along with its
+// secret and done is true. Expired links are deleted when spec.deleteOnExpiry is set.
+func (r *GoReconciler) checkSchedule(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, secErr error) (result ctrl.Result, done bool, err error) {
+	logger := log.FromContext(ctx)
+	now := time.Now()
+	expiresAt := cr.ExpiryTime()
+
+	if expiresAt != nil && !now.Before(expiresAt.Time) {
+		if cr.Spec.DeleteOnExpiry {
+			logger.Info("link expired, deleting resource", "expiresAt", expiresAt.Time)
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventExpired, "go/%s expired, deleting the resource", cr.Spec.Alias)
+			if err := r.Delete(ctx, cr); err != nil && !errors.IsNotFound(err) {
+				logger.Error(err, "failed to delete expired resource", "reason", ReasonInternalError)
+				return retry(), true, reconcileError(ReasonInternalError, err)
+			}
+			setStatus(cr, "go/"+cr.Spec.Alias+" expired", Deleting)
+			return complete, true, nil
+		}
+		if err := r.deactivate(ctx, cr, secret, secErr); err != nil {
+			return retry(), true, err
+		}
+		if cr.Status.State != Expired {
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, EventExpired, "go/%s expired and was removed from the link server", cr.Spec.Alias)
+		}
+		message := "go/" + cr.Spec.Alias + " expired at " + expiresAt.Format(time.RFC3339)
+		setStatus(cr, message, Expired)
+		setCondition(cr, shmilav1.ConditionSynced, metav1.ConditionFalse, ReasonExpired, message)
+		return complete, true, nil
+	}
+
+	if cr.Spec.ActiveFrom != nil && now.Before(cr.Spec.ActiveFrom.Time) {
+		if err := r.deactivate(ctx, cr, secret, secErr); err != nil {
+			return retry(), true, err
+		}
+		message := "go/" + cr.Spec.Alias + " is active from " + cr.Spec.ActiveFrom.Format(time.RFC3339)
+		setStatus(cr, message, Pending)
+		setCondition(cr, shmilav1.ConditionSynced, metav1.ConditionFalse, ReasonNotActive, message)
+		return ctrl.Result{RequeueAfter: time.Until(cr.Spec.ActiveFrom.Time)}, true, nil
+	}
+
+	if expiresAt != nil && !cr.Status.ExpiryWarned && now.Add(expiryWarning).After(expiresAt.Time) {
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, EventExpiring, "go/%s expires at %s", cr.Spec.Alias, expiresAt.Format(time.RFC3339))
+		cr.Status.ExpiryWarned = true
+	} else if expiresAt == nil || now.Add(expiryWarning).Before(expiresAt.Time) {
+		// the expiry was extended, warn again before the new one
+		cr.Status.ExpiryWarned = false
+	}
+	return complete, false, nil
+}
+
+// deactivate removes the link from the link server, the secret is created again once the link is active
+func (r *GoReconciler) deactivate(ctx context.Context, cr *shmilav1.Go, secret *corev1.Secret, secErr error) error {
+	if errors.IsNotFound(secErr) {
+		return nil
+	} else if secErr != nil {
+		log.FromContext(ctx).Error(secErr, "failed to read secret", "reason", ReasonSecretReadFailed)
+		return reconcileError(ReasonSecretReadFailed, secErr)
+	}
+	if err := r.handleDelete(ctx, secret); err != nil {
+		setFailure(cr, Failure, shmilav1.ConditionSynced, err)
+		return err
+	}
+	cr.Status.LastSyncedURL = ""
+	cr.Status.CredentialsSecretRef = nil
+	cr.Status.AdditionalAliases = nil
+	cr.Status.PreviousAlias = ""
+	cr.Status.PreviousAliasExpiresAt = nil
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+func TestReconcileSchedule(t *testing.T) {
+	inAnHour := &metav1.Time{Time: time.Now().Add(time.Hour)}
+	anHourAgo := &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	tests := []struct {
+		name        string
+		mutate      func(cr *shmilav1.Go)
+		wantLinks   []string
+		wantSecret  bool
+		wantState   string
+		wantRequeue bool
+		wantWarned  bool
+		wantEvent   string
+		wantDeleted bool
+	}{
+		{name: "publishes an active link", wantLinks: []string{"docs"}, wantSecret: true, wantState: Succees},
+		{
+			name:        "holds a link that is not active yet",
+			mutate:      func(cr *shmilav1.Go) { cr.Spec.ActiveFrom = inAnHour },
+			wantLinks:   []string{},
+			wantState:   Pending,
+			wantRequeue: true,
+		},
+		{
+			name:       "warns before the link expires",
+			mutate:     func(cr *shmilav1.Go) { cr.Spec.ExpiresAt = inAnHour },
+			wantLinks:  []string{"docs"},
+			wantSecret: true,
+			wantState:  Succees,
+			wantWarned: true,
+			wantEvent:  EventExpiring,
+		},
+		{
+			name:      "removes an expired link from the link server",
+			mutate:    func(cr *shmilav1.Go) { cr.Spec.ExpiresAt = anHourAgo },
+			wantLinks: []string{},
+			wantState: Expired,
+			wantEvent: EventExpired,
+		},
+		{
+			name: "deletes an expired resource with deleteOnExpiry",
+			mutate: func(cr *shmilav1.Go) {
+				cr.Spec.ExpiresAt = anHourAgo
+				cr.Spec.DeleteOnExpiry = true
+			},
+			wantLinks:   []string{"docs"},
+			wantSecret:  true,
+			wantEvent:   EventExpired,
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := testGo(tt.mutate)
+			backend := newFakeBackend(golink.Link{Alias: "docs", Url: testURL, Password: "password"})
+			r, recorder := newTestReconciler(backend, cr, testSecret(cr, nil))
+
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cr)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := backend.aliases(); !reflect.DeepEqual(got, tt.wantLinks) {
+				t.Errorf("links %v, want %v", got, tt.wantLinks)
+			}
+			if secret := getSecret(t, r, cr); (secret != nil) != tt.wantSecret {
+				t.Errorf("secret kept %t, want %t", secret != nil, tt.wantSecret)
+			}
+			if tt.wantRequeue && (result.RequeueAfter <= 59*time.Minute || result.RequeueAfter > time.Hour) {
+				t.Errorf("requeue after %s, want a requeue at the activation", result.RequeueAfter)
+			}
+			if tt.wantEvent != "" && !hasEvent(recorder, tt.wantEvent) {
+				t.Errorf("no %s event", tt.wantEvent)
+			}
+			got := getGo(t, r, cr)
+			if deleted := got.DeletionTimestamp != nil; deleted != tt.wantDeleted {
+				t.Errorf("resource deleted %t, want %t", deleted, tt.wantDeleted)
+			}
+			if tt.wantDeleted {
+				return
+			}
+			if got.Status.State != tt.wantState {
+				t.Errorf("state %q, want %q", got.Status.State, tt.wantState)
+			}
+			if got.Status.ExpiryWarned != tt.wantWarned {
+				t.Errorf("expiry warned %t, want %t", got.Status.ExpiryWarned, tt.wantWarned)
+			}
+		})
+	}
+}
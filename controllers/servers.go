@@ -0,0 +1,202 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/environment"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+// ServerRegistry resolves the link server of Go resources and keeps a
+// backend per GoLinkServer, rebuilt whenever the GoLinkServer changes.
+// Credentials are read from the auth secret of the server and reloaded
+// whenever the secret changes.
+type ServerRegistry struct {
+	Client client.Reader
+	// Metadata reads the metadata of auth secrets from a cache, the secret itself is only read when its resourceVersion changed.
+	// When it is nil the auth secret is read on every call.
+	Metadata client.Reader
+	// Default is used for Go resources without a serverRef when no GoLinkServer is marked as default
+	Default golink.GoLinkBackend
+	// DefaultAuthSecret is the name of the secret in the controller namespace with the credentials of Default
+	DefaultAuthSecret string
+
+	mu       sync.Mutex
+	backends map[string]*registeredBackend
+}
+
+type registeredBackend struct {
+	generation int64
+	// resourceVersion of the auth secret the credentials were loaded from
+	authVersion string
+	backend     golink.GoLinkBackend
+}
+
+// Resolve returns the name of the server a Go resource with serverRef is
+// published to, the empty name stands for the operator default server.
+// When several GoLinkServers are marked as default the first by name is used.
+func (s *ServerRegistry) Resolve(ctx context.Context, serverRef string) (string, error) {
+	if serverRef != "" {
+		return serverRef, nil
+	}
+	servers := shmilav1.GoLinkServerList{}
+	if err := s.Client.List(ctx, &servers); err != nil {
+		return "", err
+	}
+	defaults := []string{}
+	for _, server := range servers.Items {
+		if server.Spec.Default {
+			defaults = append(defaults, server.Name)
+		}
+	}
+	if len(defaults) > 0 {
+		sort.Strings(defaults)
+		if len(defaults) > 1 {
+			log.FromContext(ctx).Info("several link servers are marked as default, using the first by name", "servers", defaults)
+		}
+		return defaults[0], nil
+	}
+	if s.Default == nil {
+		return "", fmt.Errorf("serverRef is not set and there is no default link server")
+	}
+	return "", nil
+}
+
+// Backend returns the backend of the named server, the empty name stands for the operator default server
+func (s *ServerRegistry) Backend(ctx context.Context, name string) (golink.GoLinkBackend, error) {
+	if name == "" {
+		if s.Default == nil {
+			return nil, fmt.Errorf("there is no default link server")
+		}
+		s.mu.Lock()
+		registered := s.register(name, 0, s.Default)
+		s.mu.Unlock()
+		return registered.backend, s.authenticate(ctx, registered, s.DefaultAuthSecret)
+	}
+
+	server := shmilav1.GoLinkServer{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Name: name}, &server); err != nil {
+		return nil, fmt.Errorf("failed to get link server %s: %w", name, err)
+	}
+	authSecret := ""
+	if server.Spec.AuthSecretRef != nil {
+		authSecret = server.Spec.AuthSecretRef.Name
+	}
+
+	s.mu.Lock()
+	registered, ok := s.backends[name]
+	if !ok || registered.generation != server.Generation {
+		backend, err := newBackend(&server)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+		registered = s.register(name, server.Generation, backend)
+	}
+	s.mu.Unlock()
+	return registered.backend, s.authenticate(ctx, registered, authSecret)
+}
+
+// register stores backend under name unless it is already registered with the same generation
+func (s *ServerRegistry) register(name string, generation int64, backend golink.GoLinkBackend) *registeredBackend {
+	if registered, ok := s.backends[name]; ok && registered.generation == generation {
+		return registered
+	}
+	if s.backends == nil {
+		s.backends = map[string]*registeredBackend{}
+	}
+	registered := &registeredBackend{generation: generation, backend: backend}
+	s.backends[name] = registered
+	return registered
+}
+
+// authenticate loads the credentials of the auth secret into the backend when the secret changed since they were last loaded.
+// The secret is read without holding the registry lock, so a slow api server does not block the other links.
+func (s *ServerRegistry) authenticate(ctx context.Context, registered *registeredBackend, authSecret string) error {
+	authenticator, ok := registered.backend.(golink.Authenticator)
+	if !ok {
+		return nil
+	}
+	if authSecret == "" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if registered.authVersion != "" {
+			if err := authenticator.SetCredentials(golink.Credentials{}); err != nil {
+				return err
+			}
+			registered.authVersion = ""
+		}
+		return nil
+	}
+
+	key := client.ObjectKey{Name: authSecret, Namespace: environment.GetVariables().ControllerNamespace}
+	if s.Metadata != nil {
+		meta := metav1.PartialObjectMetadata{}
+		meta.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+		if err := s.Metadata.Get(ctx, key, &meta); err == nil && s.loaded(registered, meta.ResourceVersion) {
+			return nil
+		}
+	}
+	secret := corev1.Secret{}
+	if err := s.Client.Get(ctx, key, &secret); err != nil {
+		return fmt.Errorf("failed to get auth secret %s: %w", authSecret, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret.ResourceVersion == registered.authVersion {
+		return nil
+	}
+	if err := authenticator.SetCredentials(golink.CredentialsFromSecret(secret.Data)); err != nil {
+		return fmt.Errorf("invalid credentials in auth secret %s: %w", authSecret, err)
+	}
+	log.FromContext(ctx).Info("loaded link server credentials", "secret", authSecret)
+	registered.authVersion = secret.ResourceVersion
+	return nil
+}
+
+// loaded returns whether the credentials of the given auth secret version are loaded into registered
+func (s *ServerRegistry) loaded(registered *registeredBackend, authVersion string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return authVersion != "" && registered.authVersion == authVersion
+}
+
+func newBackend(server *shmilav1.GoLinkServer) (golink.GoLinkBackend, error) {
+	timeout := time.Duration(server.Spec.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = time.Duration(environment.GetVariables().HttpRequestTimeoutSeconds) * time.Second
+	}
+	switch server.Spec.APIFlavor {
+	case "", shmilav1.APIFlavorShmila:
+		return golink.NewRESTBackend(server.Spec.Url, timeout).WithPatterns(server.Spec.SupportsURLPatterns), nil
+	default:
+		return nil, fmt.Errorf("link server %s has an unknown api flavor %s", server.Name, server.Spec.APIFlavor)
+	}
+}
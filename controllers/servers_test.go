@@ -0,0 +1,91 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+func testServer(name string, isDefault bool) *shmilav1.GoLinkServer {
+	return &shmilav1.GoLinkServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       shmilav1.GoLinkServerSpec{Url: "https://" + name + ".example.com", Default: isDefault},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		servers   []client.Object
+		serverRef string
+		want      string
+	}{
+		{name: "server ref", servers: []client.Object{testServer("a", true)}, serverRef: "b", want: "b"},
+		{name: "operator default", servers: []client.Object{testServer("a", false)}, want: ""},
+		{name: "default server", servers: []client.Object{testServer("a", false), testServer("b", true)}, want: "b"},
+		{name: "several default servers", servers: []client.Object{testServer("c", true), testServer("b", true), testServer("a", false)}, want: "b"},
+	}
+	for _, tt := range tests {
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(tt.servers...).Build()
+		registry := &ServerRegistry{Client: c, Default: newFakeBackend()}
+		for i := 0; i < 5; i++ {
+			got, err := registry.Resolve(context.Background(), tt.serverRef)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s: resolved %q, want %q", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestBackendOfDeletedServer(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(testScheme).Build()
+	registry := &ServerRegistry{Client: c}
+	_, err := registry.Backend(context.Background(), "deleted")
+	if !isServerGone(reconcileError(ReasonServerNotFound, err)) {
+		t.Errorf("error %v, want a deleted server", err)
+	}
+}
+
+func TestReconcileMoveFromDeletedServer(t *testing.T) {
+	cr := testGo(nil)
+	backend := newFakeBackend()
+	r, recorder := newTestReconciler(backend, cr, testSecret(cr, map[string]string{"server": "deleted"}))
+
+	if err := reconcileGo(t, r, cr); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := backend.link("docs"); !ok {
+		t.Error("link was not created on the new server")
+	}
+	if server := secretValue(getSecret(t, r, cr), "server"); server != "" {
+		t.Errorf("secret server %q, want the operator default", server)
+	}
+	if !hasEvent(recorder, EventServerGone) {
+		t.Errorf("no %s event", EventServerGone)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+const (
+	Failure  string = "Failure"
+	Succees  string = "Active"
+	Pending  string = "Pending"
+	Deleting string = "Deleting"
+	Expired  string = "Expired"
+)
+
+// Condition reasons
+const (
+	ReasonLinkReady             string = "LinkReady"
+	ReasonLinkSynced            string = "LinkSynced"
+	ReasonAliasOwned            string = "AliasOwned"
+	ReasonAliasTaken            string = "AliasTaken"
+	ReasonSecretReady           string = "SecretReady"
+	ReasonSecretReadFailed      string = "SecretReadFailed"
+	ReasonSecretCreateFailed    string = "SecretCreateFailed"
+	ReasonBackendError          string = "BackendError"
+	ReasonBackendUnavailable    string = "BackendUnavailable"
+	ReasonSecretDeleteFailed    string = "SecretDeleteFailed"
+	ReasonSecretUpdateFailed    string = "SecretUpdateFailed"
+	ReasonServerNotFound        string = "ServerNotFound"
+	ReasonLinkDeleteFailed      string = "LinkDeleteFailed"
+	ReasonFinalizerUpdateFailed string = "FinalizerUpdateFailed"
+	ReasonInternalError         string = "InternalError"
+	ReasonPolicyAllowed         string = "PolicyAllowed"
+	ReasonPolicyViolation       string = "PolicyViolation"
+	ReasonDeleting              string = "Deleting"
+	ReasonReconciling           string = "Reconciling"
+	ReasonRotationFailed        string = "PasswordRotationFailed"
+	ReasonAdoptionFailed        string = "AdoptionFailed"
+	ReasonPasswordAdopted       string = "PasswordAdopted"
+	ReasonAliasesSynced         string = "AliasesSynced"
+	ReasonAliasesFailed         string = "AliasesFailed"
+	ReasonNotActive             string = "NotActive"
+	ReasonExpired               string = "Expired"
+	ReasonTargetReachable       string = "TargetReachable"
+	ReasonBrokenLink            string = "BrokenLink"
+	ReasonTargetNotResolved     string = "TargetNotResolved"
+	ReasonURLNotResolved        string = "URLNotResolved"
+)
+
+// Event reasons
+const (
+	EventCreated            string = "Created"
+	EventUpdated            string = "Updated"
+	EventAliasTaken         string = "AliasTaken"
+	EventBackendUnavailable string = "BackendUnavailable"
+	EventDeleted            string = "Deleted"
+	EventCleanupOrphan      string = "CleanupOrphan"
+	EventDrifted            string = "Drifted"
+	EventPasswordRotated    string = "PasswordRotated"
+	EventAdopted            string = "Adopted"
+	EventRetained           string = "Retained"
+	EventRenamed            string = "Renamed"
+	EventExpiring           string = "Expiring"
+	EventExpired            string = "Expired"
+	EventBrokenLink         string = "BrokenLink"
+	EventServerGone         string = "ServerGone"
+)
+
+func setStatus(cr *shmilav1.Go, message, state string) {
+	cr.Status.Message = message
+	cr.Status.State = state
+	cr.Status.ReconcileTime = time.Now().Format(time.RFC3339)
+}
+
+func setCondition(cr *shmilav1.Go, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: cr.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setFailure reports err on both the state and the failing condition
+func setFailure(cr *shmilav1.Go, state, conditionType string, err error) {
+	setStatus(cr, err.Error(), state)
+	setCondition(cr, conditionType, metav1.ConditionFalse, reasonOf(err), err.Error())
+}
+
+// setReadyCondition sums up the other conditions into the Ready condition
+func setReadyCondition(cr *shmilav1.Go) {
+	if cr.Status.State == Deleting {
+		setCondition(cr, shmilav1.ConditionReady, metav1.ConditionFalse, ReasonDeleting, cr.Status.Message)
+		return
+	}
+	// links outside of their activation window or blocked by a policy may have no credentials to report on
+	if synced := meta.FindStatusCondition(cr.Status.Conditions, shmilav1.ConditionSynced); synced != nil &&
+		(synced.Reason == ReasonNotActive || synced.Reason == ReasonExpired || synced.Reason == ReasonPolicyViolation) {
+		setCondition(cr, shmilav1.ConditionReady, metav1.ConditionFalse, synced.Reason, synced.Message)
+		return
+	}
+	for _, conditionType := range []string{
+		shmilav1.ConditionCredentialsReady,
+		shmilav1.ConditionAliasAvailable,
+		shmilav1.ConditionSynced,
+	} {
+		condition := meta.FindStatusCondition(cr.Status.Conditions, conditionType)
+		if condition == nil {
+			setCondition(cr, shmilav1.ConditionReady, metav1.ConditionFalse, ReasonReconciling, "waiting for "+conditionType)
+			return
+		}
+		if condition.Status != metav1.ConditionTrue {
+			setCondition(cr, shmilav1.ConditionReady, metav1.ConditionFalse, condition.Reason, condition.Message)
+			return
+		}
+	}
+	setCondition(cr, shmilav1.ConditionReady, metav1.ConditionTrue, ReasonLinkReady, cr.Status.Message)
+}
+
+func (r *GoReconciler) updateStatus(ctx context.Context, cr *shmilav1.Go) {
+	cr.Status.ObservedGeneration = cr.Generation
+	setReadyCondition(cr)
+	if err := r.Status().Update(ctx, cr); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update status")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+const testURL = "https://docs.example.com"
+
+// testNamespace is the controller namespace of the tests
+const testNamespace = "go-operator-test"
+
+var testScheme = runtime.NewScheme()
+
+func TestMain(m *testing.M) {
+	_ = clientgoscheme.AddToScheme(testScheme)
+	_ = shmilav1.AddToScheme(testScheme)
+	// the environment is read on first use, after this
+	os.Setenv("CONTROLLER_NAMESPACE", testNamespace)
+	os.Exit(m.Run())
+}
+
+// testGo returns the docs Go resource, already carrying the finalizer
+func testGo(mutate func(cr *shmilav1.Go)) *shmilav1.Go {
+	cr := &shmilav1.Go{
+		ObjectMeta: metav1.ObjectMeta{Name: "docs", Namespace: "default", Finalizers: []string{goFinalizer}},
+		Spec:       shmilav1.GoSpec{Alias: "docs", Url: testURL},
+	}
+	if mutate != nil {
+		mutate(cr)
+	}
+	return cr
+}
+
+// testSecret returns the credentials secret of cr with data on top of the defaults
+func testSecret(cr *shmilav1.Go, data map[string]string) *corev1.Secret {
+	secret := getSecretObject(cr.Name, cr.Namespace, testNamespace)
+	secret.Data = map[string][]byte{
+		"alias":             []byte(cr.Spec.Alias),
+		"password":          []byte("password"),
+		"resourceName":      []byte(cr.Name),
+		"resourceNamespace": []byte(cr.Namespace),
+		"server":            []byte(""),
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return &secret
+}
+
+func newTestReconciler(backend golink.GoLinkBackend, objs ...client.Object) (*GoReconciler, *record.FakeRecorder) {
+	c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objs...).Build()
+	recorder := record.NewFakeRecorder(100)
+	return &GoReconciler{
+		Client:   c,
+		Scheme:   testScheme,
+		Servers:  &ServerRegistry{Client: c, Default: backend},
+		Recorder: recorder,
+	}, recorder
+}
+
+func reconcileGo(t *testing.T, r *GoReconciler, cr *shmilav1.Go) error {
+	t.Helper()
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cr)})
+	return err
+}
+
+// getSecret returns the secret of cr, or nil when it does not exist
+func getSecret(t *testing.T, r *GoReconciler, cr *shmilav1.Go) *corev1.Secret {
+	t.Helper()
+	secret := getSecretObject(cr.Name, cr.Namespace, testNamespace)
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(&secret), &secret); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return &secret
+}
+
+func getGo(t *testing.T, r *GoReconciler, cr *shmilav1.Go) *shmilav1.Go {
+	t.Helper()
+	got := &shmilav1.Go{}
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(cr), got); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+// hasEvent returns whether an event with reason was recorded
+func hasEvent(recorder *record.FakeRecorder, reason string) bool {
+	found := false
+	for {
+		select {
+		case event := <-recorder.Events:
+			if strings.Contains(event, " "+reason+" ") {
+				found = true
+			}
+		default:
+			return found
+		}
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+var (
+	httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+	gatewayGVK   = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"}
+)
+
+func targetKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// resolveURL sets status.resolvedURL to spec.url, to the url read or rendered from
+// spec.urlFrom and spec.urlTemplate, or to the url resolved from spec.targetRef.
+// A url read from a Secret is redacted in the status and kept in r.secretURLs.
+func (r *GoReconciler) resolveURL(ctx context.Context, cr *shmilav1.Go) error {
+	key := client.ObjectKeyFromObject(cr)
+	r.secretURLs.Delete(key)
+	ref := cr.Spec.TargetRef
+	if ref == nil {
+		rendered, err := cr.RenderURL(ctx, r.Client)
+		if err == nil {
+			err = shmilav1.ValidateURL(rendered.URL)
+		}
+		if err != nil {
+			reconcileErr := reconcileError(ReasonURLNotResolved, err)
+			log.FromContext(ctx).Error(err, "failed to resolve url", "reason", reconcileErr.Reason, "sources", cr.URLSources())
+			setFailure(cr, Failure, shmilav1.ConditionSynced, reconcileErr)
+			return reconcileErr
+		}
+		if rendered.Redacted != "" {
+			r.secretURLs.Store(key, rendered.URL)
+		}
+		cr.Status.ResolvedURL = rendered.Display()
+		return nil
+	}
+	resolved, err := r.resolveTarget(ctx, cr.Namespace, ref)
+	if err != nil {
+		reconcileErr := reconcileError(ReasonTargetNotResolved, err)
+		log.FromContext(ctx).Error(err, "failed to resolve target", "reason", reconcileErr.Reason, "kind", ref.Kind, "name", ref.Name)
+		setFailure(cr, Failure, shmilav1.ConditionSynced, reconcileErr)
+		return reconcileErr
+	}
+	cr.Status.ResolvedURL = resolved
+	return nil
+}
+
+// targetURL returns the url that cr redirects to, status.resolvedURL unless it is redacted
+func (r *GoReconciler) targetURL(cr *shmilav1.Go) string {
+	if url, ok := r.secretURLs.Load(client.ObjectKeyFromObject(cr)); ok {
+		return url.(string)
+	}
+	return cr.Status.ResolvedURL
+}
+
+// resolveTarget returns the externally reachable url of the object ref points to
+func (r *GoReconciler) resolveTarget(ctx context.Context, namespace string, ref *shmilav1.GoTargetRef) (string, error) {
+	key := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+	var scheme, host string
+	var err error
+	switch ref.Kind {
+	case shmilav1.TargetKindService:
+		service := corev1.Service{}
+		if err := r.Get(ctx, key, &service); err != nil {
+			return "", err
+		}
+		scheme, host, err = serviceHost(&service, ref.Port)
+	case shmilav1.TargetKindIngress:
+		ingress := networkingv1.Ingress{}
+		if err := r.Get(ctx, key, &ingress); err != nil {
+			return "", err
+		}
+		scheme, host, err = ingressHost(&ingress, ref.Host)
+	case shmilav1.TargetKindHTTPRoute:
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGVK)
+		if err := r.Get(ctx, key, route); err != nil {
+			return "", err
+		}
+		scheme, host, err = r.httpRouteHost(ctx, route, ref.Host)
+	default:
+		return "", fmt.Errorf("unsupported target kind %s", ref.Kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	return scheme + "://" + host + ref.Path, nil
+}
+
+// serviceHost returns the external host of a LoadBalancer or ExternalName service,
+// the port is part of the host unless it is the default port of the scheme
+func serviceHost(service *corev1.Service, portRef string) (string, string, error) {
+	var host string
+	switch service.Spec.Type {
+	case corev1.ServiceTypeExternalName:
+		host = service.Spec.ExternalName
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if host = ingress.Hostname; host == "" {
+				host = ingress.IP
+			}
+			if host != "" {
+				break
+			}
+		}
+		if host == "" {
+			return "", "", fmt.Errorf("service %s has no load balancer address yet", service.Name)
+		}
+	default:
+		return "", "", fmt.Errorf("service %s of type %s is not reachable from outside the cluster", service.Name, service.Spec.Type)
+	}
+
+	if len(service.Spec.Ports) == 0 {
+		return "https", host, nil
+	}
+	port := service.Spec.Ports[0]
+	if portRef != "" {
+		found := false
+		for _, p := range service.Spec.Ports {
+			if p.Name == portRef || strconv.Itoa(int(p.Port)) == portRef {
+				port, found = p, true
+				break
+			}
+		}
+		if !found {
+			return "", "", fmt.Errorf("service %s has no port %s", service.Name, portRef)
+		}
+	}
+	scheme := "http"
+	if port.Port == 443 || port.Name == "https" {
+		scheme = "https"
+	}
+	if (scheme == "http" && port.Port != 80) || (scheme == "https" && port.Port != 443) {
+		host = host + ":" + strconv.Itoa(int(port.Port))
+	}
+	return scheme, host, nil
+}
+
+// ingressHost returns the host of an ingress rule, served over https when a tls section covers it
+func ingressHost(ingress *networkingv1.Ingress, hostRef string) (string, string, error) {
+	host := ""
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" && (hostRef == "" || rule.Host == hostRef) {
+			host = rule.Host
+			break
+		}
+	}
+	if host == "" && hostRef == "" {
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			if host = lb.Hostname; host == "" {
+				host = lb.IP
+			}
+			if host != "" {
+				break
+			}
+		}
+	}
+	if host == "" {
+		if hostRef != "" {
+			return "", "", fmt.Errorf("ingress %s has no rule for host %s", ingress.Name, hostRef)
+		}
+		return "", "", fmt.Errorf("ingress %s has no host", ingress.Name)
+	}
+
+	for _, tls := range ingress.Spec.TLS {
+		for _, tlsHost := range tls.Hosts {
+			if hostMatches(tlsHost, host) {
+				return "https", host, nil
+			}
+		}
+	}
+	return "http", host, nil
+}
+
+// httpRouteHost returns a hostname of an HTTPRoute, served over https when a
+// listener of one of its parent gateways terminates TLS for the hostname
+func (r *GoReconciler) httpRouteHost(ctx context.Context, route *unstructured.Unstructured, hostRef string) (string, string, error) {
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	host := ""
+	for _, hostname := range hostnames {
+		if !strings.Contains(hostname, "*") && (hostRef == "" || hostname == hostRef) {
+			host = hostname
+			break
+		}
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("httproute %s has no hostname to link to", route.GetName())
+	}
+
+	parents, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	for _, parent := range parents {
+		parentRef, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, _, _ := unstructured.NestedString(parentRef, "kind"); kind != "" && kind != gatewayGVK.Kind {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(parentRef, "name")
+		namespace, _, _ := unstructured.NestedString(parentRef, "namespace")
+		if namespace == "" {
+			namespace = route.GetNamespace()
+		}
+		sectionName, _, _ := unstructured.NestedString(parentRef, "sectionName")
+
+		gateway := &unstructured.Unstructured{}
+		gateway.SetGroupVersionKind(gatewayGVK)
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, gateway); err != nil {
+			log.FromContext(ctx).V(1).Info("failed to get gateway of route", "gateway", name, "error", err.Error())
+			continue
+		}
+		listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+		for _, item := range listeners {
+			listener, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			listenerName, _, _ := unstructured.NestedString(listener, "name")
+			hostname, _, _ := unstructured.NestedString(listener, "hostname")
+			protocol, _, _ := unstructured.NestedString(listener, "protocol")
+			if sectionName != "" && sectionName != listenerName {
+				continue
+			}
+			if hostname != "" && !hostMatches(hostname, host) {
+				continue
+			}
+			if protocol == "HTTPS" {
+				return "https", host, nil
+			}
+		}
+	}
+	return "http", host, nil
+}
+
+// hostMatches matches host against a hostname pattern that may start with a "*." wildcard
+func hostMatches(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:]) && !strings.Contains(strings.TrimSuffix(host, pattern[1:]), ".")
+	}
+	return pattern == host
+}
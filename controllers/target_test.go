@@ -0,0 +1,224 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+func TestServiceHost(t *testing.T) {
+	loadBalancer := func(ports ...corev1.ServicePort) *corev1.Service {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+		service.Spec.Ports = ports
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+		return service
+	}
+	tests := []struct {
+		name       string
+		service    *corev1.Service
+		port       string
+		wantScheme string
+		wantHost   string
+		wantErr    bool
+	}{
+		{
+			name:       "external name",
+			service:    &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "web.example.com"}},
+			wantScheme: "https", wantHost: "web.example.com",
+		},
+		{name: "load balancer on port 80", service: loadBalancer(corev1.ServicePort{Port: 80}), wantScheme: "http", wantHost: "203.0.113.10"},
+		{name: "load balancer on port 443", service: loadBalancer(corev1.ServicePort{Port: 443}), wantScheme: "https", wantHost: "203.0.113.10"},
+		{
+			name:       "https port by name on another port",
+			service:    loadBalancer(corev1.ServicePort{Name: "http", Port: 80}, corev1.ServicePort{Name: "https", Port: 8443}),
+			port:       "https",
+			wantScheme: "https", wantHost: "203.0.113.10:8443",
+		},
+		{
+			name:       "port by number",
+			service:    loadBalancer(corev1.ServicePort{Port: 80}, corev1.ServicePort{Port: 8080}),
+			port:       "8080",
+			wantScheme: "http", wantHost: "203.0.113.10:8080",
+		},
+		{
+			name: "load balancer hostname",
+			service: func() *corev1.Service {
+				service := loadBalancer(corev1.ServicePort{Port: 443})
+				service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+				return service
+			}(),
+			wantScheme: "https", wantHost: "lb.example.com",
+		},
+		{name: "missing port", service: loadBalancer(corev1.ServicePort{Port: 80}), port: "https", wantErr: true},
+		{
+			name: "load balancer without an address",
+			service: func() *corev1.Service {
+				service := loadBalancer(corev1.ServicePort{Port: 80})
+				service.Status.LoadBalancer.Ingress = nil
+				return service
+			}(),
+			wantErr: true,
+		},
+		{name: "cluster ip", service: &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		scheme, host, err := serviceHost(tt.service, tt.port)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.wantErr)
+		} else if scheme != tt.wantScheme || host != tt.wantHost {
+			t.Errorf("%s: got %s://%s, want %s://%s", tt.name, scheme, host, tt.wantScheme, tt.wantHost)
+		}
+	}
+}
+
+func TestIngressHost(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "web.example.com"}, {Host: "api.example.com"}},
+			TLS:   []networkingv1.IngressTLS{{Hosts: []string{"*.example.com"}}},
+		},
+	}
+	plain := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "plain"},
+		Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{}}},
+	}
+	plain.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+	tests := []struct {
+		name       string
+		ingress    *networkingv1.Ingress
+		host       string
+		wantScheme string
+		wantHost   string
+		wantErr    bool
+	}{
+		{name: "first rule", ingress: ingress, wantScheme: "https", wantHost: "web.example.com"},
+		{name: "rule by host", ingress: ingress, host: "api.example.com", wantScheme: "https", wantHost: "api.example.com"},
+		{name: "missing host", ingress: ingress, host: "other.example.com", wantErr: true},
+		{name: "load balancer address without tls", ingress: plain, wantScheme: "http", wantHost: "203.0.113.10"},
+		{name: "host is not looked up on the load balancer", ingress: plain, host: "203.0.113.10", wantErr: true},
+	}
+	for _, tt := range tests {
+		scheme, host, err := ingressHost(tt.ingress, tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.wantErr)
+		} else if scheme != tt.wantScheme || host != tt.wantHost {
+			t.Errorf("%s: got %s://%s, want %s://%s", tt.name, scheme, host, tt.wantScheme, tt.wantHost)
+		}
+	}
+}
+
+func TestHTTPRouteHost(t *testing.T) {
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "public", "namespace": "gateways"},
+		"spec": map[string]interface{}{"listeners": []interface{}{
+			map[string]interface{}{"name": "http", "protocol": "HTTP"},
+			map[string]interface{}{"name": "https", "protocol": "HTTPS", "hostname": "*.example.com"},
+		}},
+	}}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	route := func(sectionName string, hostnames ...interface{}) *unstructured.Unstructured {
+		parentRef := map[string]interface{}{"name": "public", "namespace": "gateways"}
+		if sectionName != "" {
+			parentRef["sectionName"] = sectionName
+		}
+		route := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web", "namespace": "default"},
+			"spec":     map[string]interface{}{"hostnames": hostnames, "parentRefs": []interface{}{parentRef}},
+		}}
+		route.SetGroupVersionKind(httpRouteGVK)
+		return route
+	}
+	r, _ := newTestReconciler(newFakeBackend(), gateway)
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(gateway), gateway.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		route      *unstructured.Unstructured
+		host       string
+		wantScheme string
+		wantHost   string
+		wantErr    bool
+	}{
+		{name: "https listener", route: route("", "*.wildcard.example.com", "web.example.com"), wantScheme: "https", wantHost: "web.example.com"},
+		{name: "http section", route: route("http", "web.example.com"), wantScheme: "http", wantHost: "web.example.com"},
+		{name: "hostname outside the https listener", route: route("", "web.example.org"), wantScheme: "http", wantHost: "web.example.org"},
+		{name: "hostname by name", route: route("", "web.example.com", "api.example.com"), host: "api.example.com", wantScheme: "https", wantHost: "api.example.com"},
+		{name: "only wildcard hostnames", route: route("", "*.example.com"), wantErr: true},
+	}
+	for _, tt := range tests {
+		scheme, host, err := r.httpRouteHost(context.Background(), tt.route, tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.wantErr)
+		} else if scheme != tt.wantScheme || host != tt.wantHost {
+			t.Errorf("%s: got %s://%s, want %s://%s", tt.name, scheme, host, tt.wantScheme, tt.wantHost)
+		}
+	}
+}
+
+func TestResolveURLFromSecret(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantReason  string
+	}{
+		{name: "annotated", annotations: map[string]string{shmilav1.URLSourceAnnotation: "true"}},
+		{name: "not annotated", wantReason: ReasonURLNotResolved},
+		{name: "annotated false", annotations: map[string]string{shmilav1.URLSourceAnnotation: "false"}, wantReason: ReasonURLNotResolved},
+	}
+	for _, tt := range tests {
+		cr := testGo(func(cr *shmilav1.Go) {
+			cr.Spec.Url = ""
+			cr.Spec.UrlFrom = &shmilav1.GoURLSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "link"},
+				Key:                  "url",
+			}}
+		})
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "link", Namespace: cr.Namespace, Annotations: tt.annotations},
+			Data:       map[string][]byte{"url": []byte("https://internal.example.com")},
+		}
+		r, _ := newTestReconciler(newFakeBackend(), secret)
+
+		err := r.resolveURL(context.Background(), cr)
+		if tt.wantReason != "" {
+			if reasonOf(err) != tt.wantReason {
+				t.Errorf("%s: got %v, want reason %s", tt.name, err, tt.wantReason)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if got := r.targetURL(cr); got != "https://internal.example.com" {
+			t.Errorf("%s: target url %q, want the url of the secret", tt.name, got)
+		} else if cr.Status.ResolvedURL == got {
+			t.Errorf("%s: status shows the url of the secret unredacted", tt.name)
+		}
+	}
+}
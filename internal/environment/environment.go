@@ -4,28 +4,61 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type EnvironmentVariables struct {
 	GoApiURL                  string
+	GoApiAuthSecret           string
 	ControllerNamespace       string
 	SecretPrefix              string
 	CleanIntervalSeconds      int
 	RetryTimeSeconds          int
 	HttpRequestTimeoutSeconds int
+	ResyncIntervalSeconds     int
+	RotationIntervalSeconds   int
+	ProbeRequestsPerMinute    int
+	ProbeBurst                int
+	ProbeAllowPrivate         bool
+	GoApiSupportsURLPatterns  bool
+	RedirectServerAddr        string
+	RedirectPermanent         bool
 }
 
 var variables *EnvironmentVariables = nil
 
+// invalid holds the variables that fell back to their default since their value could not be parsed
+var invalid []string
+
+// Load reads the variables like GetVariables, and returns the values that could not be parsed
+// and fell back to their default as an error. main calls it once the logger is set, so the
+// error can be logged.
+func Load() (*EnvironmentVariables, error) {
+	env := GetVariables()
+	if len(invalid) > 0 {
+		return env, fmt.Errorf("invalid environment variables, using their defaults: %s", strings.Join(invalid, ", "))
+	}
+	return env, nil
+}
+
 func GetVariables() *EnvironmentVariables {
 	if variables == nil {
 		variables = &EnvironmentVariables{
-			GoApiURL:                  getenvOrDie("GO_API_SERVER"),
+			GoApiURL:                  getenv("GO_API_SERVER", ""),
+			GoApiAuthSecret:           getenv("GO_API_AUTH_SECRET", ""),
 			ControllerNamespace:       getenvOrDie("CONTROLLER_NAMESPACE"),
 			SecretPrefix:              getenv("SECRET_PREFIX", "go-"),
 			CleanIntervalSeconds:      getenvInt("CLEAN_INTERVAL_SECONDS", 15*60),
 			RetryTimeSeconds:          getenvInt("RETRY_TIME_SECONDS", 30),
 			HttpRequestTimeoutSeconds: getenvInt("HTTP_REQUEST_TIMEOUT_SECONDS", 3),
+			ResyncIntervalSeconds:     getenvInt("RESYNC_INTERVAL_SECONDS", 10*60),
+			RotationIntervalSeconds:   getenvInt("PASSWORD_ROTATION_INTERVAL_SECONDS", 0),
+			ProbeRequestsPerMinute:    getenvInt("PROBE_REQUESTS_PER_MINUTE", 30),
+			ProbeBurst:                getenvInt("PROBE_BURST", 5),
+			ProbeAllowPrivate:         getenvBool("PROBE_ALLOW_PRIVATE_ADDRESSES", false),
+			GoApiSupportsURLPatterns:  getenvBool("GO_API_SUPPORTS_URL_PATTERNS", false),
+			RedirectServerAddr:        getenv("REDIRECT_SERVER_ADDR", ""),
+			RedirectPermanent:         getenvBool("REDIRECT_PERMANENT", false),
 		}
 	}
 	return variables
@@ -38,7 +71,7 @@ func getenvInt(key string, fallback int) int {
 	} else {
 		ret, err := strconv.Atoi(value)
 		if err != nil {
-			fmt.Printf("[ERROR - getenvInt] failed to read value for %s, %s cannot be converted to int", key, value)
+			invalid = append(invalid, fmt.Sprintf("%s=%q is not an int (default %d)", key, value, fallback))
 			return fallback
 		} else {
 			return ret
@@ -46,6 +79,19 @@ func getenvInt(key string, fallback int) int {
 	}
 }
 
+func getenvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return fallback
+	}
+	ret, err := strconv.ParseBool(value)
+	if err != nil {
+		invalid = append(invalid, fmt.Sprintf("%s=%q is not a bool (default %t)", key, value, fallback))
+		return fallback
+	}
+	return ret
+}
+
 func getenv(key, fallback string) string {
 	value := os.Getenv(key)
 	if len(value) == 0 {
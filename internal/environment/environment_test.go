@@ -0,0 +1,23 @@
+package environment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadReportsInvalidValues(t *testing.T) {
+	t.Setenv("CONTROLLER_NAMESPACE", "go-operator")
+	t.Setenv("RETRY_TIME_SECONDS", "soon")
+	t.Setenv("REDIRECT_PERMANENT", "maybe")
+	t.Setenv("RESYNC_INTERVAL_SECONDS", "60")
+	variables, invalid = nil, nil
+	defer func() { variables, invalid = nil, nil }()
+
+	env, err := Load()
+	if env.RetryTimeSeconds != 30 || env.RedirectPermanent || env.ResyncIntervalSeconds != 60 {
+		t.Errorf("variables %+v, want the defaults of the invalid values", env)
+	}
+	if err == nil || !strings.Contains(err.Error(), "RETRY_TIME_SECONDS") || !strings.Contains(err.Error(), "REDIRECT_PERMANENT") {
+		t.Errorf("error %v, want both invalid variables", err)
+	}
+}
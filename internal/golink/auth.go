@@ -0,0 +1,80 @@
+package golink
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// Secret keys that hold link server credentials
+const (
+	TokenKey      = "token"
+	UsernameKey   = "username"
+	PasswordKey   = "password"
+	ClientCertKey = "tls.crt"
+	ClientKeyKey  = "tls.key"
+	CACertKey     = "ca.crt"
+)
+
+// Credentials authenticate the operator to a link server
+type Credentials struct {
+	BearerToken string
+	Username    string
+	Password    string
+	// PEM encoded client certificate and key for mTLS
+	ClientCert []byte
+	ClientKey  []byte
+	// PEM encoded CA bundle to verify the link server with
+	CACert []byte
+}
+
+// CredentialsFromSecret reads credentials from the data of a secret
+func CredentialsFromSecret(data map[string][]byte) Credentials {
+	return Credentials{
+		BearerToken: string(data[TokenKey]),
+		Username:    string(data[UsernameKey]),
+		Password:    string(data[PasswordKey]),
+		ClientCert:  data[ClientCertKey],
+		ClientKey:   data[ClientKeyKey],
+		CACert:      data[CACertKey],
+	}
+}
+
+// Authenticator is implemented by backends that can authenticate to the link server
+type Authenticator interface {
+	// SetCredentials replaces the credentials used by all following requests
+	SetCredentials(credentials Credentials) error
+}
+
+// authorize adds the token or basic auth header to req
+func (c *Credentials) authorize(req *http.Request) {
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// tlsConfig returns the client certificate and CA bundle as a tls config, or nil when there are none
+func (c *Credentials) tlsConfig() (*tls.Config, error) {
+	if len(c.ClientCert) == 0 && len(c.CACert) == 0 {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(c.ClientCert) > 0 {
+		cert, err := tls.X509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if len(c.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.CACert) {
+			return nil, fmt.Errorf("invalid CA bundle")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
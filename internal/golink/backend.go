@@ -0,0 +1,74 @@
+package golink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Link is a single go link as the link server knows it
+type Link struct {
+	Alias    string
+	Url      string
+	Password string
+	// UrlPattern and PathPassthrough are only sent to a PatternBackend that supports them
+	UrlPattern      string
+	PathPassthrough bool
+}
+
+// PatternBackend is implemented by backends that know whether their link server
+// expands url patterns and passes the path after the alias through
+type PatternBackend interface {
+	SupportsPatterns() bool
+}
+
+// SupportsPatterns returns whether the link server of backend expands url patterns
+func SupportsPatterns(backend GoLinkBackend) bool {
+	patternBackend, ok := backend.(PatternBackend)
+	return ok && patternBackend.SupportsPatterns()
+}
+
+// GoLinkBackend is a link server that go links are published to.
+// Implementations must be safe for concurrent use.
+type GoLinkBackend interface {
+	// Upsert creates the link, or updates it when the password matches the existing one
+	Upsert(ctx context.Context, link Link) error
+	// ChangePassword replaces the password of the link, it returns ErrAliasTaken
+	// when password does not match and ErrNotFound when the link does not exist
+	ChangePassword(ctx context.Context, alias, password, newPassword string) error
+	// Delete removes the link, deleting a missing link is not an error
+	Delete(ctx context.Context, alias, password string) error
+	// Get returns the link without its password, or ErrNotFound. It returns
+	// ErrUnsupported when the link server has no way to read a link.
+	Get(ctx context.Context, alias string) (*Link, error)
+	// List returns all the links on the server without their passwords, or ErrUnsupported
+	List(ctx context.Context) ([]Link, error)
+}
+
+var (
+	// ErrAliasTaken is returned when the alias is owned by someone else on the link server
+	ErrAliasTaken = errors.New("alias already taken")
+	// ErrNotFound is returned when the link does not exist on the link server
+	ErrNotFound = errors.New("link not found")
+	// ErrUnsupported is returned when the link server does not have the endpoint of an operation
+	ErrUnsupported = errors.New("not supported by the link server")
+)
+
+// maxErrorBody is how much of the response body a StatusError quotes, its message ends up in conditions and events
+const maxErrorBody = 512
+
+// StatusError is returned when the link server answers with an unexpected status code
+type StatusError struct {
+	Operation  string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBody {
+		body = strings.ToValidUTF8(body[:maxErrorBody], "") + "..."
+	}
+	return fmt.Sprintf("%s request failed with status %d: %s", e.Operation, e.StatusCode, body)
+}
@@ -0,0 +1,28 @@
+package golink
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "go_operator_link_api_requests_total",
+			Help: "Number of requests sent to the link API by operation and status code",
+		},
+		[]string{"operation", "code"},
+	)
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "go_operator_link_api_request_duration_seconds",
+			Help:    "Duration of requests sent to the link API by operation",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"operation"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(requestsTotal, requestDuration)
+}
@@ -0,0 +1,244 @@
+package golink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const linksPath = "/api/v1/go-links"
+
+// RESTBackend talks to the link-shortener REST API
+type RESTBackend struct {
+	baseURL  string
+	timeout  time.Duration
+	patterns bool
+
+	mu          sync.RWMutex
+	httpClient  *http.Client
+	credentials Credentials
+
+	// canGet is set once the link server answered a get, the get endpoint is not part of
+	// every link server so until then a 404 may as well mean that the endpoint is missing
+	canGet int32
+}
+
+var _ GoLinkBackend = &RESTBackend{}
+var _ Authenticator = &RESTBackend{}
+var _ PatternBackend = &RESTBackend{}
+
+type restLink struct {
+	Alias        string `json:"alias"`
+	Url          string `json:"url,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PasswordHint string `json:"passwordHint,omitempty"`
+	NewPassword  string `json:"newPassword,omitempty"`
+
+	UrlPattern      string `json:"urlPattern,omitempty"`
+	PathPassthrough bool   `json:"pathPassthrough,omitempty"`
+}
+
+// NewRESTBackend returns a backend for the link server listening on baseURL
+func NewRESTBackend(baseURL string, timeout time.Duration) *RESTBackend {
+	return &RESTBackend{
+		baseURL:    baseURL,
+		timeout:    timeout,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// WithPatterns marks the link server as one that expands url patterns and passes paths through
+func (b *RESTBackend) WithPatterns(patterns bool) *RESTBackend {
+	b.patterns = patterns
+	return b
+}
+
+func (b *RESTBackend) SupportsPatterns() bool {
+	return b.patterns
+}
+
+func (b *RESTBackend) SetCredentials(credentials Credentials) error {
+	tlsConfig, err := credentials.tlsConfig()
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: b.timeout}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		httpClient.Transport = transport
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.httpClient = httpClient
+	b.credentials = credentials
+	return nil
+}
+
+func (b *RESTBackend) Upsert(ctx context.Context, link Link) error {
+	body := restLink{
+		Alias:        link.Alias,
+		Url:          link.Url,
+		Password:     link.Password,
+		PasswordHint: "managed by go-operator",
+	}
+	if b.patterns {
+		body.UrlPattern = link.UrlPattern
+		body.PathPassthrough = link.PathPassthrough
+	}
+	res, err := b.post(ctx, "upsert", linksPath, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized {
+		return ErrAliasTaken
+	}
+	if res.StatusCode/100 != 2 {
+		return statusError("upsert", res)
+	}
+	return nil
+}
+
+func (b *RESTBackend) ChangePassword(ctx context.Context, alias, password, newPassword string) error {
+	res, err := b.post(ctx, "change-password", linksPath+"/password", restLink{
+		Alias:       alias,
+		Password:    password,
+		NewPassword: newPassword,
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized {
+		return ErrAliasTaken
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode/100 != 2 {
+		return statusError("change-password", res)
+	}
+	return nil
+}
+
+func (b *RESTBackend) Delete(ctx context.Context, alias, password string) error {
+	res, err := b.post(ctx, "delete", linksPath+"/delete", restLink{Alias: alias, Password: password})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 && res.StatusCode != http.StatusNotFound {
+		return statusError("delete", res)
+	}
+	return nil
+}
+
+func (b *RESTBackend) Get(ctx context.Context, alias string) (*Link, error) {
+	res, err := b.get(ctx, "get", linksPath+"/"+url.PathEscape(alias))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ErrUnsupported
+	}
+	if res.StatusCode == http.StatusNotFound {
+		if atomic.LoadInt32(&b.canGet) == 0 {
+			return nil, ErrUnsupported
+		}
+		return nil, ErrNotFound
+	}
+	if res.StatusCode/100 != 2 {
+		return nil, statusError("get", res)
+	}
+	atomic.StoreInt32(&b.canGet, 1)
+	link := restLink{}
+	if err := json.NewDecoder(res.Body).Decode(&link); err != nil {
+		return nil, err
+	}
+	return &Link{Alias: link.Alias, Url: link.Url, UrlPattern: link.UrlPattern, PathPassthrough: link.PathPassthrough}, nil
+}
+
+func (b *RESTBackend) List(ctx context.Context) ([]Link, error) {
+	res, err := b.get(ctx, "list", linksPath)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ErrUnsupported
+	}
+	if res.StatusCode/100 != 2 {
+		return nil, statusError("list", res)
+	}
+	links := []restLink{}
+	if err := json.NewDecoder(res.Body).Decode(&links); err != nil {
+		return nil, err
+	}
+	ret := make([]Link, 0, len(links))
+	for _, link := range links {
+		ret = append(ret, Link{Alias: link.Alias, Url: link.Url})
+	}
+	return ret, nil
+}
+
+func (b *RESTBackend) post(ctx context.Context, operation, path string, body restLink) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+path, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return b.do(operation, req)
+}
+
+func (b *RESTBackend) get(ctx context.Context, operation, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	return b.do(operation, req)
+}
+
+// do sends the request and records it in the link API metrics
+func (b *RESTBackend) do(operation string, req *http.Request) (*http.Response, error) {
+	b.mu.RLock()
+	httpClient := b.httpClient
+	b.credentials.authorize(req)
+	b.mu.RUnlock()
+
+	start := time.Now()
+	res, err := httpClient.Do(req)
+	requestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		requestsTotal.WithLabelValues(operation, "error").Inc()
+		return nil, err
+	}
+	requestsTotal.WithLabelValues(operation, strconv.Itoa(res.StatusCode)).Inc()
+	return res, nil
+}
+
+func statusError(operation string, res *http.Response) error {
+	// only the start of the body is quoted, a link server may answer with a whole error page
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBody+1))
+	return &StatusError{Operation: operation, StatusCode: res.StatusCode, Body: string(body)}
+}
@@ -0,0 +1,149 @@
+package golink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRESTBackendStatusMapping(t *testing.T) {
+	ctx := context.Background()
+	operations := map[string]func(b *RESTBackend) error{
+		"upsert": func(b *RESTBackend) error {
+			return b.Upsert(ctx, Link{Alias: "docs", Url: "https://docs.example.com", Password: "secret"})
+		},
+		"change-password": func(b *RESTBackend) error {
+			return b.ChangePassword(ctx, "docs", "secret", "new-secret")
+		},
+		"delete": func(b *RESTBackend) error {
+			return b.Delete(ctx, "docs", "secret")
+		},
+		"get": func(b *RESTBackend) error {
+			_, err := b.Get(ctx, "docs")
+			return err
+		},
+		"list": func(b *RESTBackend) error {
+			_, err := b.List(ctx)
+			return err
+		},
+	}
+
+	tests := []struct {
+		operation string
+		status    int
+		want      error
+		// wantStatus expects a StatusError with the answered status code
+		wantStatus bool
+	}{
+		{operation: "upsert", status: http.StatusOK},
+		{operation: "upsert", status: http.StatusCreated},
+		{operation: "upsert", status: http.StatusForbidden, want: ErrAliasTaken},
+		{operation: "upsert", status: http.StatusUnauthorized, want: ErrAliasTaken},
+		{operation: "upsert", status: http.StatusInternalServerError, wantStatus: true},
+		{operation: "change-password", status: http.StatusOK},
+		{operation: "change-password", status: http.StatusForbidden, want: ErrAliasTaken},
+		{operation: "change-password", status: http.StatusUnauthorized, want: ErrAliasTaken},
+		{operation: "change-password", status: http.StatusNotFound, want: ErrNotFound},
+		{operation: "change-password", status: http.StatusBadGateway, wantStatus: true},
+		{operation: "delete", status: http.StatusOK},
+		{operation: "delete", status: http.StatusNotFound},
+		{operation: "delete", status: http.StatusForbidden, wantStatus: true},
+		{operation: "get", status: http.StatusOK},
+		{operation: "get", status: http.StatusNotFound, want: ErrUnsupported},
+		{operation: "get", status: http.StatusMethodNotAllowed, want: ErrUnsupported},
+		{operation: "get", status: http.StatusInternalServerError, wantStatus: true},
+		{operation: "list", status: http.StatusOK},
+		{operation: "list", status: http.StatusServiceUnavailable, wantStatus: true},
+		{operation: "list", status: http.StatusNotFound, want: ErrUnsupported},
+		{operation: "list", status: http.StatusMethodNotAllowed, want: ErrUnsupported},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(tt.status)
+			if tt.status/100 == 2 && req.Method == http.MethodGet {
+				if tt.operation == "list" {
+					_, _ = w.Write([]byte(`[{"alias":"docs","url":"https://docs.example.com"}]`))
+				} else {
+					_, _ = w.Write([]byte(`{"alias":"docs","url":"https://docs.example.com"}`))
+				}
+			}
+		}))
+		err := operations[tt.operation](NewRESTBackend(server.URL, time.Second))
+		server.Close()
+
+		var statusErr *StatusError
+		switch {
+		case tt.wantStatus:
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status || statusErr.Operation != tt.operation {
+				t.Errorf("%s answered %d: got %v, want a %s StatusError with status %d", tt.operation, tt.status, err, tt.operation, tt.status)
+			}
+		case !errors.Is(err, tt.want):
+			t.Errorf("%s answered %d: got %v, want %v", tt.operation, tt.status, err, tt.want)
+		}
+	}
+}
+
+func TestRESTBackendSendsPatternsOnlyWhenSupported(t *testing.T) {
+	for _, patterns := range []bool{false, true} {
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ = ioutil.ReadAll(req.Body)
+		}))
+		backend := NewRESTBackend(server.URL, time.Second).WithPatterns(patterns)
+		err := backend.Upsert(context.Background(), Link{Alias: "issue", Url: "https://issues.example.com", UrlPattern: "https://issues.example.com/%s"})
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bytes.Contains(body, []byte(`"urlPattern"`)); got != patterns {
+			t.Errorf("patterns %t: urlPattern sent = %t, body %s", patterns, got, body)
+		}
+	}
+}
+
+func TestRESTBackendGetNotFoundOnceGetWorks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != linksPath+"/docs" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write([]byte(`{"alias":"docs","url":"https://docs.example.com"}`))
+	}))
+	defer server.Close()
+	backend := NewRESTBackend(server.URL, time.Second)
+
+	// before any link was read, a 404 may come from a link server without the get endpoint
+	if _, err := backend.Get(context.Background(), "missing"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("first get of a missing link: %v, want %v", err, ErrUnsupported)
+	}
+	if _, err := backend.Get(context.Background(), "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get of a missing link: %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestStatusErrorQuotesTheStartOfTheBody(t *testing.T) {
+	page := "<html>" + strings.Repeat("x", 100000) + "</html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	err := NewRESTBackend(server.URL, time.Second).Upsert(context.Background(), Link{Alias: "docs", Url: "https://docs.example.com"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("got %v, want a StatusError", err)
+	}
+	if message := err.Error(); len(message) > maxErrorBody+100 || !strings.HasPrefix(statusErr.Body, "<html>") {
+		t.Errorf("error of %d characters quoting %q..., want the start of the body", len(message), message[:50])
+	}
+}
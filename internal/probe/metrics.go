@@ -0,0 +1,18 @@
+package probe
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var probeDuration = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "go_operator_target_probe_duration_seconds",
+		Help:    "Duration of probes sent to link targets",
+		Buckets: prometheus.DefBuckets,
+	},
+)
+
+func init() {
+	metrics.Registry.MustRegister(probeDuration)
+}
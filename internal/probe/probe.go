@@ -0,0 +1,206 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Options configure a single probe
+type Options struct {
+	// Method is HEAD or GET, a HEAD that is not allowed is retried as a GET
+	Method  string
+	Timeout time.Duration
+	// FollowRedirects follows redirects and checks the status code of the final response
+	FollowRedirects bool
+	// AcceptedStatusCodes are the status codes of a reachable target, any 2xx or 3xx when empty
+	AcceptedStatusCodes []int
+}
+
+// Result of probing a target
+type Result struct {
+	Reachable  bool
+	StatusCode int
+	// Err is set when no response was received
+	Err      error
+	Duration time.Duration
+}
+
+// RateLimitedError is returned when the host of the target was probed too often,
+// the probe should be retried after RetryAfter
+type RateLimitedError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("probes of %s are rate limited, retry after %s", e.Host, e.RetryAfter)
+}
+
+// ErrAddressNotAllowed is the error of a probe whose target resolves to a loopback, link-local
+// or, unless they are allowed, private address. Probes must not reach into the cluster network.
+var ErrAddressNotAllowed = errors.New("the address of the target is not allowed")
+
+// Prober probes link targets, rate limited per target host.
+// It is safe for concurrent use.
+type Prober struct {
+	limit rate.Limit
+	burst int
+	// idle is how long a limiter is kept unused, by then it has its full burst again and
+	// forgetting it changes nothing. Zero keeps the limiters forever.
+	idle      time.Duration
+	transport *http.Transport
+	// allowAddress tells whether probes may connect to an address
+	allowAddress func(ip net.IP) bool
+	allowPrivate bool
+
+	mu       sync.Mutex
+	limiters map[string]*hostLimiter
+	// evicted is when the idle limiters were last removed
+	evicted time.Time
+}
+
+// hostLimiter is the rate limiter of a single host
+type hostLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
+}
+
+// NewProber returns a prober that sends at most perMinute probes a minute to each host
+func NewProber(perMinute, burst int) *Prober {
+	p := &Prober{
+		limit:    rate.Limit(float64(perMinute) / 60),
+		burst:    burst,
+		limiters: map[string]*hostLimiter{},
+		evicted:  time.Now(),
+	}
+	if p.limit > 0 {
+		p.idle = time.Duration(float64(burst) / float64(p.limit) * float64(time.Second))
+	}
+	p.allowAddress = p.publicAddress
+	dialer := &net.Dialer{Control: p.control}
+	p.transport = http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be the only address checked, the target is always connected directly
+	p.transport.Proxy = nil
+	p.transport.DialContext = dialer.DialContext
+	return p
+}
+
+// WithPrivateAddresses allows probing targets with a private address, such as the
+// services of the cluster. Loopback and link-local addresses are never probed.
+func (p *Prober) WithPrivateAddresses(allow bool) *Prober {
+	p.allowPrivate = allow
+	return p
+}
+
+// control rejects connections to addresses that are not allowed. It runs for the resolved
+// address of every connection, so redirects and DNS answers can not get around it.
+func (p *Prober) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !p.allowAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+	}
+	return nil
+}
+
+func (p *Prober) publicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	return p.allowPrivate || !ip.IsPrivate()
+}
+
+// Probe sends a request to rawURL, failing to reach the target is reported in the
+// result and only an invalid url or a rate limited host are returned as errors
+func (p *Prober) Probe(ctx context.Context, rawURL string, opts Options) (*Result, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	reservation := p.limiter(target.Host).Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return nil, &RateLimitedError{Host: target.Host, RetryAfter: delay}
+	}
+
+	httpClient := &http.Client{Transport: p.transport, Timeout: opts.Timeout}
+	if !opts.FollowRedirects {
+		httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	method := opts.Method
+	if method == "" {
+		method = http.MethodHead
+	}
+	start := time.Now()
+	statusCode, err := send(ctx, httpClient, method, rawURL)
+	if err == nil && method == http.MethodHead && statusCode == http.StatusMethodNotAllowed {
+		statusCode, err = send(ctx, httpClient, http.MethodGet, rawURL)
+	}
+	result := &Result{StatusCode: statusCode, Err: err, Duration: time.Since(start)}
+	result.Reachable = err == nil && accepted(statusCode, opts.AcceptedStatusCodes)
+	probeDuration.Observe(result.Duration.Seconds())
+	return result, nil
+}
+
+// limiter returns the limiter of host, and forgets the limiters of hosts that were not probed for a while
+func (p *Prober) limiter(host string) *rate.Limiter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.idle > 0 && now.Sub(p.evicted) >= p.idle {
+		for h, limiter := range p.limiters {
+			if now.Sub(limiter.lastUsed) >= p.idle {
+				delete(p.limiters, h)
+			}
+		}
+		p.evicted = now
+	}
+	limiter, ok := p.limiters[host]
+	if !ok {
+		limiter = &hostLimiter{Limiter: rate.NewLimiter(p.limit, p.burst)}
+		p.limiters[host] = limiter
+	}
+	limiter.lastUsed = now
+	return limiter.Limiter
+}
+
+func send(ctx context.Context, httpClient *http.Client, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "go-operator-probe")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+func accepted(statusCode int, acceptedStatusCodes []int) bool {
+	if len(acceptedStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 400
+	}
+	for _, code := range acceptedStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestProber returns a prober that may reach the loopback address of httptest servers
+func newTestProber(perMinute, burst int) *Prober {
+	p := NewProber(perMinute, burst)
+	p.allowAddress = func(net.IP) bool { return true }
+	return p
+}
+
+func TestProbe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, req *http.Request) {})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "/missing", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path       string
+		opts       Options
+		reachable  bool
+		statusCode int
+	}{
+		{path: "/ok", reachable: true, statusCode: http.StatusOK},
+		{path: "/missing", reachable: false, statusCode: http.StatusNotFound},
+		{path: "/missing", opts: Options{AcceptedStatusCodes: []int{http.StatusNotFound}}, reachable: true, statusCode: http.StatusNotFound},
+		{path: "/get-only", reachable: true, statusCode: http.StatusOK},
+		{path: "/moved", reachable: true, statusCode: http.StatusFound},
+		{path: "/moved", opts: Options{FollowRedirects: true}, reachable: false, statusCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		tt.opts.Timeout = time.Second
+		result, err := newTestProber(60, 10).Probe(context.Background(), server.URL+tt.path, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if result.Reachable != tt.reachable || result.StatusCode != tt.statusCode {
+			t.Errorf("%s %+v: reachable %t status %d, want reachable %t status %d",
+				tt.path, tt.opts, result.Reachable, result.StatusCode, tt.reachable, tt.statusCode)
+		}
+	}
+}
+
+func TestProbeRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	prober := newTestProber(1, 1)
+	if _, err := prober.Probe(context.Background(), server.URL, Options{Timeout: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := prober.Probe(context.Background(), server.URL, Options{Timeout: time.Second})
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter <= 0 {
+		t.Errorf("second probe: got %v, want a RateLimitedError", err)
+	}
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	result, err := newTestProber(60, 10).Probe(context.Background(), url, Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reachable || result.Err == nil {
+		t.Errorf("closed server: reachable %t err %v, want unreachable with an error", result.Reachable, result.Err)
+	}
+}
+
+func TestProbeAddressNotAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	for _, allowPrivate := range []bool{false, true} {
+		result, err := NewProber(60, 10).WithPrivateAddresses(allowPrivate).Probe(context.Background(), server.URL, Options{Timeout: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Reachable || !errors.Is(result.Err, ErrAddressNotAllowed) {
+			t.Errorf("loopback with private addresses %t: reachable %t err %v, want %v", allowPrivate, result.Reachable, result.Err, ErrAddressNotAllowed)
+		}
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		ip           string
+		allowPrivate bool
+		want         bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1::1", want: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "169.254.169.254"},
+		{ip: "169.254.169.254", allowPrivate: true},
+		{ip: "fe80::1", allowPrivate: true},
+		{ip: "0.0.0.0", allowPrivate: true},
+		{ip: "10.0.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "fd00::1"},
+		{ip: "10.0.0.1", allowPrivate: true, want: true},
+		{ip: "fd00::1", allowPrivate: true, want: true},
+	}
+	for _, tt := range tests {
+		p := NewProber(60, 10).WithPrivateAddresses(tt.allowPrivate)
+		if got := p.publicAddress(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("%s with private addresses %t: allowed %t, want %t", tt.ip, tt.allowPrivate, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterEviction(t *testing.T) {
+	p := NewProber(60, 10)
+	p.limiter("idle.example.com")
+	p.limiter("busy.example.com")
+	p.limiters["idle.example.com"].lastUsed = time.Now().Add(-time.Hour)
+	p.evicted = time.Now().Add(-time.Hour)
+
+	p.limiter("busy.example.com")
+	if _, ok := p.limiters["idle.example.com"]; ok {
+		t.Error("the limiter of an idle host was kept")
+	}
+	if _, ok := p.limiters["busy.example.com"]; !ok {
+		t.Error("the limiter of a busy host was removed")
+	}
+}
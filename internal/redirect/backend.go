@@ -0,0 +1,63 @@
+package redirect
+
+import (
+	"context"
+
+	"github.com/Guyeise1/go-operator/internal/golink"
+)
+
+// Backend is the default link server when the operator serves the redirects itself.
+// The redirect server reads the links straight from the Go resources, so publishing
+// a link only records its url and Get answers from the index. The recorded urls are
+// served for links whose url is read from a Secret, since their status does not show it.
+type Backend struct {
+	index *Index
+}
+
+var _ golink.GoLinkBackend = &Backend{}
+var _ golink.PatternBackend = &Backend{}
+
+// NewBackend returns a backend for the links served from index
+func NewBackend(index *Index) *Backend {
+	return &Backend{index: index}
+}
+
+func (b *Backend) SupportsPatterns() bool {
+	return true
+}
+
+func (b *Backend) Upsert(ctx context.Context, link golink.Link) error {
+	b.index.publish(link.Alias, link.Url)
+	return nil
+}
+
+func (b *Backend) ChangePassword(ctx context.Context, alias, password, newPassword string) error {
+	return nil
+}
+
+func (b *Backend) Delete(ctx context.Context, alias, password string) error {
+	b.index.unpublish(alias)
+	return nil
+}
+
+func (b *Backend) Get(ctx context.Context, alias string) (*golink.Link, error) {
+	link, ok := b.index.Lookup(alias)
+	if !ok {
+		return nil, golink.ErrNotFound
+	}
+	ret := toLink(link)
+	return &ret, nil
+}
+
+func (b *Backend) List(ctx context.Context) ([]golink.Link, error) {
+	links := b.index.Links()
+	ret := make([]golink.Link, 0, len(links))
+	for _, link := range links {
+		ret = append(ret, toLink(link))
+	}
+	return ret, nil
+}
+
+func toLink(link Link) golink.Link {
+	return golink.Link{Alias: link.Alias, Url: link.Url, UrlPattern: link.UrlPattern, PathPassthrough: link.PathPassthrough}
+}
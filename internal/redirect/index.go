@@ -0,0 +1,235 @@
+package redirect
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+// Link is a link served by the redirect server
+type Link struct {
+	Alias string
+	// Url is empty for a link whose url is read from a Secret, the status only has it redacted
+	Url             string
+	UrlPattern      string
+	PathPassthrough bool
+	Object          types.NamespacedName
+}
+
+// Index maps aliases to the links of the Go resources that are published to the
+// operator default server, it is kept up to date by the Go informer of the manager cache
+type Index struct {
+	mu       sync.RWMutex
+	links    map[string]Link
+	byObject map[types.NamespacedName][]string
+	// published holds the urls the controller published through Backend, by alias,
+	// they are served for links whose url is read from a Secret
+	published map[string]string
+}
+
+// NewIndex returns an empty index, Watch fills it
+func NewIndex() *Index {
+	return &Index{
+		links:     map[string]Link{},
+		byObject:  map[types.NamespacedName][]string{},
+		published: map[string]string{},
+	}
+}
+
+// Watch registers the index with the Go informer of informers, the informer
+// starts with the manager cache and replays the existing resources
+func (i *Index) Watch(ctx context.Context, informers cache.Informers) error {
+	informer, err := informers.GetInformer(ctx, &shmilav1.Go{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cr, ok := obj.(*shmilav1.Go); ok {
+				i.set(cr)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if cr, ok := obj.(*shmilav1.Go); ok {
+				i.set(cr)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cr, ok := obj.(*shmilav1.Go); ok {
+				i.remove(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+			}
+		},
+	})
+	return nil
+}
+
+// set replaces the links of cr with the aliases it has synced, a link is served
+// once the controller synced it and until it is deleted or deactivated
+func (i *Index) set(cr *shmilav1.Go) {
+	key := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.removeLocked(key)
+	if cr.DeletionTimestamp != nil || cr.Status.Server != "" || cr.Status.LastSyncedURL == "" {
+		return
+	}
+
+	aliases := []string{cr.Spec.Alias}
+	if cr.Status.PreviousAlias != "" {
+		aliases = append(aliases, cr.Status.PreviousAlias)
+	}
+	for _, status := range cr.Status.AdditionalAliases {
+		if status.LastSyncedURL != "" {
+			aliases = append(aliases, status.Alias)
+		}
+	}
+	url := cr.Status.LastSyncedURL
+	if cr.URLFromSecret() {
+		url = ""
+	}
+	for _, alias := range aliases {
+		i.links[alias] = Link{
+			Alias:           alias,
+			Url:             url,
+			UrlPattern:      cr.Spec.UrlPattern,
+			PathPassthrough: cr.Spec.PathPassthrough,
+			Object:          key,
+		}
+	}
+	i.byObject[key] = aliases
+}
+
+func (i *Index) remove(key types.NamespacedName) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.removeLocked(key)
+}
+
+func (i *Index) removeLocked(key types.NamespacedName) {
+	for _, alias := range i.byObject[key] {
+		// the alias may have moved to another resource since
+		if i.links[alias].Object == key {
+			delete(i.links, alias)
+		}
+	}
+	delete(i.byObject, key)
+}
+
+// publish records the url the controller published for alias
+func (i *Index) publish(alias, url string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.published[alias] = url
+}
+
+func (i *Index) unpublish(alias string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.published, alias)
+}
+
+// Lookup returns the link of alias, a link read from a Secret is only found once it was published
+func (i *Index) Lookup(alias string) (Link, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	link, ok := i.links[alias]
+	if ok && link.Url == "" {
+		link.Url, ok = i.published[alias]
+	}
+	return link, ok
+}
+
+// Links returns all the served links sorted by alias
+func (i *Index) Links() []Link {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	links := make([]Link, 0, len(i.links))
+	for _, link := range i.links {
+		if link.Url == "" {
+			if link.Url = i.published[link.Alias]; link.Url == "" {
+				continue
+			}
+		}
+		links = append(links, link)
+	}
+	sort.Slice(links, func(a, b int) bool { return links[a].Alias < links[b].Alias })
+	return links
+}
+
+// Suggest returns up to max aliases that are close to alias, closest first
+func (i *Index) Suggest(alias string, max int) []string {
+	type candidate struct {
+		alias    string
+		distance int
+	}
+	// a third of the alias may be mistyped, short aliases get at least two edits
+	limit := len([]rune(alias)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+
+	i.mu.RLock()
+	candidates := []candidate{}
+	for other := range i.links {
+		if distance := levenshtein(alias, other); distance <= limit {
+			candidates = append(candidates, candidate{other, distance})
+		}
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].distance != candidates[b].distance {
+			return candidates[a].distance < candidates[b].distance
+		}
+		return candidates[a].alias < candidates[b].alias
+	})
+	suggestions := []string{}
+	for _, c := range candidates {
+		if len(suggestions) == max {
+			break
+		}
+		suggestions = append(suggestions, c.alias)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
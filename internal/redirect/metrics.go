@@ -0,0 +1,18 @@
+package redirect
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var redirectsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "go_operator_redirects_total",
+		Help: "Requests answered by the built-in redirect server, by result",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(redirectsTotal)
+}
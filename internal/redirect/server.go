@@ -0,0 +1,191 @@
+package redirect
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
+)
+
+// maxSuggestions is the number of similar aliases on the not found page
+const maxSuggestions = 5
+
+var notFoundPage = template.Must(template.New("not-found").Parse(`<!DOCTYPE html>
+<html>
+<head><title>go/{{ .Alias }} not found</title></head>
+<body>
+<h1>go/{{ .Alias }} does not exist</h1>
+{{- if .Suggestions }}
+<p>Did you mean:</p>
+<ul>
+{{- range .Suggestions }}
+<li><a href="/{{ . }}">go/{{ . }}</a></li>
+{{- end }}
+</ul>
+{{- end }}
+</body>
+</html>
+`))
+
+// Server answers go/alias requests with a redirect to the url of the link.
+// Only the leader serves redirects, since the urls read from Secrets are published to its
+// index by the reconciler of the leader and another replica would not find their aliases.
+type Server struct {
+	// Addr is the address the server listens on, such as ":8082"
+	Addr string
+	// Index holds the links the server redirects to
+	Index *Index
+	// Permanent answers with 301 Moved Permanently instead of 302 Found
+	Permanent bool
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+func (s *Server) NeedLeaderElection() bool {
+	return true
+}
+
+// Start serves redirects until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("redirect")
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		logger.Info("starting redirect server", "addr", s.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		logger.Info("shutting down redirect server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := []string{}
+	for _, segment := range strings.Split(strings.Trim(req.URL.Path, "/"), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		s.notFound(w, "")
+		return
+	}
+
+	link, ok := s.Index.Lookup(segments[0])
+	if !ok {
+		s.notFound(w, segments[0])
+		return
+	}
+	target, err := targetURL(link, segments[1:], req.URL.RawQuery)
+	if err != nil {
+		redirectsTotal.WithLabelValues("bad_request").Inc()
+		http.Error(w, "go/"+link.Alias+": "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code := http.StatusFound
+	if s.Permanent {
+		code = http.StatusMovedPermanently
+	}
+	redirectsTotal.WithLabelValues("redirected").Inc()
+	http.Redirect(w, req, target, code)
+}
+
+// targetURL returns the url that go/alias/path... redirects to. The path fills the
+// url pattern of the link, or is appended to its url with path passthrough, and is
+// ignored by any other link. The query of the request is kept by both.
+func targetURL(link Link, path []string, rawQuery string) (string, error) {
+	if len(path) == 0 {
+		return link.Url, nil
+	}
+	if link.UrlPattern != "" {
+		expanded, err := shmilav1.ExpandURLPattern(link.UrlPattern, path)
+		if err != nil {
+			return "", err
+		}
+		return withQuery(expanded, rawQuery), nil
+	}
+	if !link.PathPassthrough {
+		return link.Url, nil
+	}
+
+	target, err := url.Parse(link.Url)
+	if err != nil {
+		return "", err
+	}
+	escaped := make([]string, 0, len(path))
+	for _, segment := range path {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	rawPath := strings.TrimSuffix(target.EscapedPath(), "/") + "/" + strings.Join(escaped, "/")
+	if target.Path, err = url.PathUnescape(rawPath); err != nil {
+		return "", err
+	}
+	target.RawPath = rawPath
+	return withQuery(target.String(), rawQuery), nil
+}
+
+// withQuery appends rawQuery to the query of target. It works on the string since
+// an expanded url pattern may hold a literal % that does not parse as a url.
+func withQuery(target, rawQuery string) string {
+	if rawQuery == "" {
+		return target
+	}
+	fragment := ""
+	if i := strings.Index(target, "#"); i >= 0 {
+		target, fragment = target[:i], target[i:]
+	}
+	switch {
+	case !strings.Contains(target, "?"):
+		target += "?"
+	case !strings.HasSuffix(target, "?") && !strings.HasSuffix(target, "&"):
+		target += "&"
+	}
+	return target + rawQuery + fragment
+}
+
+func (s *Server) notFound(w http.ResponseWriter, alias string) {
+	redirectsTotal.WithLabelValues("not_found").Inc()
+	data := struct {
+		Alias       string
+		Suggestions []string
+	}{Alias: alias}
+	if alias != "" {
+		data.Suggestions = s.Index.Suggest(alias, maxSuggestions)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	_ = notFoundPage.Execute(w, data)
+}
@@ -0,0 +1,121 @@
+package redirect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestTargetURL(t *testing.T) {
+	plain := Link{Alias: "docs", Url: "https://docs.example.com/home"}
+	passthrough := Link{Alias: "repo", Url: "https://git.example.com/org/?tab=code", PathPassthrough: true}
+	pattern := Link{Alias: "issue", Url: "https://issues.example.com", UrlPattern: "https://issues.example.com/browse/%s"}
+
+	tests := []struct {
+		name     string
+		link     Link
+		path     []string
+		rawQuery string
+		want     string
+		wantErr  bool
+	}{
+		{name: "no path", link: pattern, want: "https://issues.example.com"},
+		{name: "plain link ignores the path", link: plain, path: []string{"x"}, rawQuery: "a=1", want: "https://docs.example.com/home"},
+		{name: "passthrough", link: passthrough, path: []string{"go-operator", "pulls"}, want: "https://git.example.com/org/go-operator/pulls?tab=code"},
+		{name: "passthrough escapes segments", link: passthrough, path: []string{"a b"}, want: "https://git.example.com/org/a%20b?tab=code"},
+		{name: "passthrough keeps the query", link: passthrough, path: []string{"x"}, rawQuery: "q=1", want: "https://git.example.com/org/x?tab=code&q=1"},
+		{name: "pattern", link: pattern, path: []string{"OPS-1"}, want: "https://issues.example.com/browse/OPS-1"},
+		{name: "pattern keeps the query", link: pattern, path: []string{"OPS-1"}, rawQuery: "focus=1", want: "https://issues.example.com/browse/OPS-1?focus=1"},
+		{
+			name:     "pattern with a query keeps the query",
+			link:     Link{Alias: "search", UrlPattern: "https://search.example.com/?q=%s"},
+			path:     []string{"kubernetes"},
+			rawQuery: "page=2",
+			want:     "https://search.example.com/?q=kubernetes&page=2",
+		},
+		{
+			name:     "pattern with a literal percent keeps the query",
+			link:     Link{Alias: "off", UrlPattern: "https://shop.example.com/100%%/%s"},
+			path:     []string{"shoes"},
+			rawQuery: "size=9",
+			want:     "https://shop.example.com/100%/shoes?size=9",
+		},
+		{name: "pattern with too many segments", link: pattern, path: []string{"OPS-1", "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := targetURL(tt.link, tt.path, tt.rawQuery)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	index := NewIndex()
+	for _, alias := range []string{"docs", "doc", "dogs", "jenkins", "jenkins-prod", "grafana"} {
+		index.links[alias] = Link{Alias: alias, Url: "https://" + alias + ".example.com"}
+	}
+
+	tests := []struct {
+		alias string
+		max   int
+		want  []string
+	}{
+		{alias: "dcos", max: 5, want: []string{"doc", "docs", "dogs"}},
+		{alias: "docs", max: 2, want: []string{"docs", "doc"}},
+		{alias: "jenkin", max: 5, want: []string{"jenkins"}},
+		{alias: "jenkins-prd", max: 5, want: []string{"jenkins-prod"}},
+		{alias: "kibana", max: 5, want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := index.Suggest(tt.alias, tt.max); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q, %d) = %v, want %v", tt.alias, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	index := NewIndex()
+	index.links["docs"] = Link{Alias: "docs", Url: "https://docs.example.com"}
+	// a link read from a Secret is only served once the controller published it
+	index.links["vault"] = Link{Alias: "vault", Object: types.NamespacedName{Namespace: "default", Name: "vault"}}
+	server := &Server{Index: index}
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{method: http.MethodGet, path: "/docs", code: http.StatusFound, location: "https://docs.example.com"},
+		{method: http.MethodHead, path: "/docs/", code: http.StatusFound, location: "https://docs.example.com"},
+		{method: http.MethodGet, path: "/vault", code: http.StatusNotFound},
+		{method: http.MethodGet, path: "/doc", code: http.StatusNotFound},
+		{method: http.MethodGet, path: "/", code: http.StatusNotFound},
+		{method: http.MethodPost, path: "/docs", code: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != tt.code {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, recorder.Code, tt.code)
+		}
+		if location := recorder.Header().Get("Location"); location != tt.location {
+			t.Errorf("%s %s: location %q, want %q", tt.method, tt.path, location, tt.location)
+		}
+	}
+
+	index.publish("vault", "https://vault.example.com")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/vault", nil))
+	if location := recorder.Header().Get("Location"); recorder.Code != http.StatusFound || location != "https://vault.example.com" {
+		t.Errorf("published vault: status %d location %q", recorder.Code, location)
+	}
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -30,12 +32,17 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	shmilav1 "github.com/Guyeise1/go-operator/api/v1"
 	"github.com/Guyeise1/go-operator/controllers"
+	"github.com/Guyeise1/go-operator/internal/environment"
+	"github.com/Guyeise1/go-operator/internal/golink"
+	"github.com/Guyeise1/go-operator/internal/probe"
+	"github.com/Guyeise1/go-operator/internal/redirect"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -94,14 +101,86 @@ func main() {
 		os.Exit(1)
 	}
 
+	// GO_API_SERVER is the default link server, unless a GoLinkServer is marked as default
+	env, err := environment.Load()
+	if err != nil {
+		setupLog.Error(err, "invalid environment")
+	}
+	var defaultBackend golink.GoLinkBackend
+	if env.GoApiURL != "" {
+		defaultBackend = golink.NewRESTBackend(env.GoApiURL, time.Duration(env.HttpRequestTimeoutSeconds)*time.Second).
+			WithPatterns(env.GoApiSupportsURLPatterns)
+	}
+
+	// REDIRECT_SERVER_ADDR makes the operator serve the redirects itself, it replaces
+	// GO_API_SERVER as the default link server and no link API is called for its links
+	if env.RedirectServerAddr != "" {
+		if env.GoApiURL != "" {
+			setupLog.Info("the redirect server replaces GO_API_SERVER as the default link server")
+		}
+		index := redirect.NewIndex()
+		if err := index.Watch(context.Background(), mgr.GetCache()); err != nil {
+			setupLog.Error(err, "unable to watch goes for the redirect server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&redirect.Server{
+			Addr:      env.RedirectServerAddr,
+			Index:     index,
+			Permanent: env.RedirectPermanent,
+		}); err != nil {
+			setupLog.Error(err, "unable to add the redirect server")
+			os.Exit(1)
+		}
+		defaultBackend = redirect.NewBackend(index)
+	}
+
+	// auth secrets are cached in the controller namespace only, the manager may not list secrets elsewhere
+	secretCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: env.ControllerNamespace,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create the secret cache")
+		os.Exit(1)
+	}
+	if err := mgr.Add(secretCache); err != nil {
+		setupLog.Error(err, "unable to add the secret cache")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.GoReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Servers: &controllers.ServerRegistry{
+			Client:            mgr.GetClient(),
+			Metadata:          secretCache,
+			Default:           defaultBackend,
+			DefaultAuthSecret: env.GoApiAuthSecret,
+		},
+		Recorder: mgr.GetEventRecorderFor("go-controller"),
+		Prober:   probe.NewProber(env.ProbeRequestsPerMinute, env.ProbeBurst).WithPrivateAddresses(env.ProbeAllowPrivate),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Go")
 		os.Exit(1)
 	}
-	if err = (&shmilav1.Go{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&controllers.IngressReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ingress-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Ingress")
+		os.Exit(1)
+	}
+	if err = (&controllers.GoLinkTemplateReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("golinktemplate-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GoLinkTemplate")
+		os.Exit(1)
+	}
+	if err = (&shmilav1.Go{}).SetupWebhookWithManager(mgr, env.ControllerNamespace); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Go")
 		os.Exit(1)
 	}